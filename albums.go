@@ -0,0 +1,154 @@
+package gphoto
+
+import (
+	log "github.com/canhlinh/log4go"
+)
+
+// CachedAlbums returns the albums seen by the last GetAlbums, updated by the album operations since.
+// GetAlbums is called if the albums were never fetched.
+func (c *Client) CachedAlbums() (Albums, error) {
+	c.albumsMu.Lock()
+	albums := c.albums
+	c.albumsMu.Unlock()
+
+	if albums != nil {
+		return albums, nil
+	}
+	return c.GetAlbums()
+}
+
+// cachedAlbum returns the cached album of the given ID, or nil. The albums aren't fetched.
+func (c *Client) cachedAlbum(albumID string) *Album {
+	c.albumsMu.Lock()
+	defer c.albumsMu.Unlock()
+
+	for _, album := range c.albums {
+		if album.ID == albumID {
+			return album
+		}
+	}
+	return nil
+}
+
+// RenameAlbum changes the name of an album
+func (c *Client) RenameAlbum(albumID, newName string) error {
+	log.Info("Request to rename album %s to %s", albumID, newName)
+
+	if _, err := c.doRPC(QueryStringRenameAlbum, []interface{}{albumID, newName}); err != nil {
+		return err
+	}
+
+	c.updateAlbumCache(func(albums Albums) Albums {
+		var updated Albums
+		for _, album := range albums {
+			if album.ID == albumID {
+				renamed := *album
+				renamed.Name = newName
+				album = &renamed
+			}
+			updated = append(updated, album)
+		}
+		return updated
+	})
+	return nil
+}
+
+// DeleteAlbum deletes an album.
+// With keepItems the media items stay in the library, otherwise they are moved to the trash once the album is deleted.
+// The trashed items disappear from every album, not only from this one.
+// If the album is deleted but some items aren't trashed, the error is a *TrashError naming them, so MoveToTrash can be tried again.
+func (c *Client) DeleteAlbum(albumID string, keepItems bool) error {
+	log.Info("Request to delete album %s", albumID)
+
+	// The items are listed first, the album can't be read once deleted
+	var ids []string
+	if !keepItems {
+		it := c.AlbumItems(albumID)
+		for {
+			photo, err := it.Next()
+			if err == ErrorIteratorDone {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			ids = append(ids, photo.ID)
+		}
+	}
+
+	if _, err := c.doRPC(QueryStringDeleteAlbum, []interface{}{[]string{albumID}, []interface{}{}}); err != nil {
+		return err
+	}
+
+	c.updateAlbumCache(func(albums Albums) Albums {
+		var updated Albums
+		for _, album := range albums {
+			if album.ID != albumID {
+				updated = append(updated, album)
+			}
+		}
+		return updated
+	})
+
+	return c.MoveToTrash(ids...)
+}
+
+func (c *Client) setAlbumCache(albums Albums) {
+	c.albumsMu.Lock()
+	defer c.albumsMu.Unlock()
+
+	if albums == nil {
+		albums = Albums{}
+	}
+	c.albums = albums
+}
+
+func (c *Client) addToAlbumCache(album *Album) {
+	c.updateAlbumCache(func(albums Albums) Albums {
+		return append(albums, album)
+	})
+}
+
+// decreaseAlbumItemCount removes the photos from the item count of the cached album
+func (c *Client) decreaseAlbumItemCount(albumID string, removed *[]string) {
+	c.addAlbumItemCount(albumID, -len(*removed))
+}
+
+// addAlbumItemCount adds delta to the item count of the cached album, which never goes below zero
+func (c *Client) addAlbumItemCount(albumID string, delta int) {
+	if delta == 0 {
+		return
+	}
+
+	c.updateAlbumCache(func(albums Albums) Albums {
+		for i, album := range albums {
+			if album.ID == albumID {
+				updated := *album
+				updated.ItemCount += delta
+				if updated.ItemCount < 0 {
+					updated.ItemCount = 0
+				}
+				albums[i] = &updated
+			}
+		}
+		return albums
+	})
+}
+
+// updateAlbumCache replaces the cached albums by the result of update.
+// Nothing is done while the albums were never fetched.
+func (c *Client) updateAlbumCache(update func(Albums) Albums) {
+	c.albumsMu.Lock()
+	defer c.albumsMu.Unlock()
+
+	if c.albums == nil {
+		return
+	}
+
+	// Albums returned earlier must not change under the caller, so always build a new slice
+	albums := update(append(Albums{}, c.albums...))
+	if albums == nil {
+		albums = Albums{}
+	}
+	c.albums = albums
+}
@@ -0,0 +1,183 @@
+package gphoto
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlbumCache(t *testing.T) {
+	client := NewClient()
+
+	t.Run("NotFetchedYet", func(t *testing.T) {
+		client.addToAlbumCache(&Album{ID: "1", Name: "One"})
+		assert.Nil(t, client.albums)
+	})
+
+	t.Run("UpdatedAfterFetch", func(t *testing.T) {
+		client.setAlbumCache(Albums{{ID: "1", Name: "One"}})
+		client.addToAlbumCache(&Album{ID: "2", Name: "Two"})
+
+		albums, err := client.CachedAlbums()
+		require.NoError(t, err)
+		require.Len(t, albums, 2)
+		assert.Equal(t, "Two", albums.Get("Two").Name)
+
+		client.updateAlbumCache(func(albums Albums) Albums {
+			return albums[1:]
+		})
+		assert.Len(t, albums, 2, "a returned slice must not change")
+
+		albums, err = client.CachedAlbums()
+		require.NoError(t, err)
+		assert.Len(t, albums, 1)
+	})
+}
+
+func TestRemoveFromAlbum(t *testing.T) {
+	var calls int
+	client := newFakeClient(func(req *http.Request) (int, string) {
+		calls++
+		if calls > 1 {
+			return http.StatusInternalServerError, ""
+		}
+		return http.StatusOK, rpcBody(QueryStringRemoveFromAlbum, `[["photo1","photo2"]]`)
+	})
+	client.setAlbumCache(Albums{{ID: "album", Name: "Album", ItemCount: 10}})
+
+	ids := make([]string, AlbumBatchSize+1)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("photo%d", i)
+	}
+
+	removed, err := client.RemoveFromAlbum("album", ids...)
+	assert.Error(t, err)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, []string{"photo1", "photo2"}, removed)
+
+	albums, err := client.CachedAlbums()
+	require.NoError(t, err)
+	assert.Equal(t, 8, albums.Get("Album").ItemCount)
+}
+
+func TestAddPhotosToAlbum(t *testing.T) {
+	var rpcs []string
+	client := newFakeClient(func(req *http.Request) (int, string) {
+		req.ParseForm()
+		rpcs = append(rpcs, queryRPCID(req.PostForm.Get("f.req")))
+		if req.URL.Query().Get("rpcids") == QueryStringGetShareInfo {
+			return http.StatusOK, rpcBody(QueryStringGetShareInfo, `[["https://photos.app.goo.gl/abcdef","SHARE_KEY",1,2]]`)
+		}
+		return http.StatusOK, ""
+	})
+	client.setAlbumCache(Albums{{ID: "album", Name: "Album", ItemCount: 1}})
+
+	t.Run("CachedAlbum", func(t *testing.T) {
+		rpcs = nil
+		ids := make([]string, AlbumBatchSize+1)
+		require.NoError(t, client.AddPhotosToAlbum("album", ids))
+		assert.Equal(t, []string{QueryStringAddPhotosToLibraryAlbum, QueryStringAddPhotosToLibraryAlbum}, rpcs)
+
+		albums, err := client.CachedAlbums()
+		require.NoError(t, err)
+		assert.Equal(t, AlbumBatchSize+2, albums.Get("Album").ItemCount)
+	})
+
+	t.Run("SharedAlbumNotCached", func(t *testing.T) {
+		rpcs = nil
+		require.NoError(t, client.AddPhotosToAlbum("shared", []string{"photo"}))
+		assert.Equal(t, []string{QueryStringGetShareInfo, QueryStringAddPhotoToAlbum}, rpcs)
+	})
+}
+
+func TestDeleteAlbum(t *testing.T) {
+	var rpcs []string
+	deleteStatus := http.StatusOK
+	trashStatus := http.StatusOK
+	client := newFakeClient(func(req *http.Request) (int, string) {
+		rpcID := req.URL.Query().Get("rpcids")
+		rpcs = append(rpcs, rpcID)
+		switch rpcID {
+		case QueryStringListAlbumItems:
+			return http.StatusOK, rpcBody(rpcID, `[[["AF1QipPhoto",["https://lh3.googleusercontent.com/photo",4032,3024],1629549478000,"dedup1",25200000]]]`)
+		case QueryStringDeleteAlbum:
+			return deleteStatus, rpcBody(rpcID, "[]")
+		case QueryStringTrashItems:
+			return trashStatus, rpcBody(rpcID, "[]")
+		}
+		return http.StatusOK, rpcBody(rpcID, "[]")
+	})
+
+	t.Run("DeleteTheAlbumThenTrashTheItems", func(t *testing.T) {
+		rpcs = nil
+		require.NoError(t, client.DeleteAlbum("album", false))
+		assert.Equal(t, []string{QueryStringListAlbumItems, QueryStringDeleteAlbum, QueryStringTrashItems}, rpcs)
+	})
+
+	t.Run("KeepItems", func(t *testing.T) {
+		rpcs = nil
+		require.NoError(t, client.DeleteAlbum("album", true))
+		assert.Equal(t, []string{QueryStringDeleteAlbum}, rpcs)
+	})
+
+	t.Run("NameTheItemsNotTrashed", func(t *testing.T) {
+		rpcs = nil
+		trashStatus = http.StatusInternalServerError
+		defer func() { trashStatus = http.StatusOK }()
+
+		err := client.DeleteAlbum("album", false)
+		var trashErr *TrashError
+		require.True(t, errors.As(err, &trashErr))
+		assert.Equal(t, []string{"AF1QipPhoto"}, trashErr.IDs)
+		assert.Equal(t, []string{QueryStringListAlbumItems, QueryStringDeleteAlbum, QueryStringTrashItems}, rpcs)
+	})
+
+	t.Run("NothingTrashedWhenTheDeleteFails", func(t *testing.T) {
+		rpcs = nil
+		deleteStatus = http.StatusInternalServerError
+		assert.Error(t, client.DeleteAlbum("album", false))
+		assert.Equal(t, []string{QueryStringListAlbumItems, QueryStringDeleteAlbum}, rpcs)
+	})
+}
+
+func TestMoveToAlbum(t *testing.T) {
+	var rpcs []string
+	client := newFakeClient(func(req *http.Request) (int, string) {
+		req.ParseForm()
+		rpcID := queryRPCID(req.PostForm.Get("f.req"))
+		rpcs = append(rpcs, rpcID)
+		if rpcID == "Z5xsfc" {
+			return http.StatusOK, rpcBody(rpcID, `[[["AF1QipWeb",null,{"72930366":[1,"Web",null,3,null]}]],null,[1]]`)
+		}
+		return http.StatusOK, ""
+	})
+	client.setAlbumCache(Albums{{ID: "AF1QipOld", Name: "Old"}})
+
+	// The album was created in the web UI after the albums were cached
+	photo, err := client.moveToAlbum("Web", "photo")
+	require.NoError(t, err)
+	assert.Equal(t, "AF1QipWeb", photo.AlbumID)
+	assert.Equal(t, []string{"Z5xsfc", QueryStringAddPhotosToLibraryAlbum}, rpcs)
+}
+
+func TestAlbumItemCountAfterAddAndRemove(t *testing.T) {
+	client := newFakeClient(func(req *http.Request) (int, string) {
+		if req.URL.Query().Get("rpcids") == QueryStringRemoveFromAlbum {
+			return http.StatusOK, rpcBody(QueryStringRemoveFromAlbum, `[["photo1"]]`)
+		}
+		return http.StatusOK, ""
+	})
+	client.setAlbumCache(Albums{{ID: "album", Name: "Album", ItemCount: 3}})
+
+	require.NoError(t, client.AddPhotosToAlbum("album", []string{"photo1", "photo2"}))
+	_, err := client.RemoveFromAlbum("album", "photo1")
+	require.NoError(t, err)
+
+	albums, err := client.CachedAlbums()
+	require.NoError(t, err)
+	assert.Equal(t, 4, albums.Get("Album").ItemCount)
+}
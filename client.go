@@ -2,6 +2,7 @@ package gphoto
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -12,6 +13,7 @@ import (
 	"os"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
@@ -42,6 +44,8 @@ const (
 	// GoogleLoginSite the url to login
 	GoogleLoginSite = "https://accounts.google.com/ServiceLogin"
 	// DefaultAlbum a required album need to do a magic thing
+	//
+	// Deprecated: Upload no longer adds the photos without album to it, they stay in the library only.
 	DefaultAlbum = "DefaultAlbum"
 )
 
@@ -51,6 +55,10 @@ var (
 	regex2         = regexp.MustCompile(`\n\[\["wrb.fr","mdpdU","(.*?)"\]\]\n`)
 	regex3         = regexp.MustCompile(`\n\[\["wrb.fr","Z5xsfc",(.*?)\]\]\n`)
 	regex4         = regexp.MustCompile(`\n\[\["wrb.fr","OXvT9d",(.*?)\]\]\n`)
+
+	// rpcRegexes the response regexes of doRPC by rpcid
+	rpcRegexes = map[string]*regexp.Regexp{}
+	rpcRegexMu sync.Mutex
 )
 
 // Client present a upload client
@@ -58,6 +66,11 @@ type Client struct {
 	hClient    *http.Client
 	magicToken string
 	uploader   *Uploader
+	metrics    Metrics
+
+	// albums the albums seen by the last GetAlbums, kept up to date by the album operations
+	albums   Albums
+	albumsMu sync.Mutex
 }
 
 // NewClient init a Client by existing cookies.
@@ -71,6 +84,7 @@ func NewClient(cookies ...*http.Cookie) *Client {
 	c := &Client{
 		hClient:  hClient,
 		uploader: NewUploader(hClient),
+		metrics:  DefaultMetrics(),
 	}
 
 	return c.SetCookies(cookies...)
@@ -159,60 +173,88 @@ func (c *Client) Login(user, pass string) error {
 
 // Upload uploads the file to the google photo.
 // We will recive an url that people can access to the uploaded file directly.
+// The photo is added to the album named album, created if it doesn't exist. With an empty album, the photo stays in the library only.
+// See UploadFile for more options.
 func (c *Client) Upload(filePath string, filename string, album string, progressHandler ProgressHandler) (*Photo, error) {
-	log.Info("Start upload file %s", filePath)
+	return c.UploadFile(context.Background(), filePath, WithFilename(filename), WithAlbum(album), WithProgress(progressHandler))
+}
 
-	file, err := os.Open(filePath)
+// UploadToAlbumID uploads the file to the google photo, and adds it to the album of the given ID
+func (c *Client) UploadToAlbumID(filePath string, filename string, albumID string, progressHandler ProgressHandler) (*Photo, error) {
+	return c.UploadFile(context.Background(), filePath, WithFilename(filename), WithAlbumID(albumID), WithProgress(progressHandler))
+}
+
+// uploadFile uploads the file to the library, without adding it to an album.
+// The stages already done by st are skipped, so a failed upload goes on from the stage which failed.
+func (c *Client) uploadFile(ctx context.Context, st *uploadState) error {
+	log.Info("Start upload file %s", st.filePath)
+
+	file, err := os.Open(st.filePath)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer file.Close()
 	fileInfo, _ := file.Stat()
 
 	// A magic token need to be genarate firstly.
-	if err := c.parseMagicToken(); err != nil {
-		return nil, err
+	if st.session == nil {
+		if err := c.parseMagicToken(); err != nil {
+			return err
+		}
 	}
 
+	filename := st.opts.filename
 	if filename == "" {
 		filename = fileInfo.Name()
 	}
+	contentType := DetectContentType(file)
+	retry := st.opts.retry
 
 	// Start create a new upload session
-	uploadURL, err := c.createUploadURL(filename, fileInfo.Size())
-	if err != nil {
-		log.Error("Failed to create upload url, got error %s", err.Error())
-		return nil, err
+	if st.session == nil {
+		err = c.runStage(ctx, StageCreateUploadURL, retry, func() (err error) {
+			st.session, err = c.CreateUploadSession(ctx, filename, fileInfo.Size())
+			return err
+		})
+		if err != nil {
+			log.Error("Failed to create upload url, got error %s", err.Error())
+			return st.fail(StageCreateUploadURL, err)
+		}
 	}
 
 	// start upload file
-	uploadToken, err := c.upload(uploadURL, file, fileInfo.Size(), progressHandler)
-	if err != nil {
-		log.Error("Failed to upload data, got error %s", err.Error())
-		return nil, err
+	if st.transfer == nil {
+		err = c.runStage(ctx, StageUpload, retry, func() (err error) {
+			if _, err := file.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			st.transfer, err = c.Transfer(ctx, st.session, file, st.opts.progressHandler)
+			return err
+		})
+		if err != nil {
+			log.Error("Failed to upload data, got error %s", err.Error())
+			return st.fail(StageUpload, err)
+		}
 	}
-	fmt.Println("uploadToken:", uploadToken)
 
-	photoID, photoURL, err := c.enableUploadedFile(uploadToken, filename, fileInfo.ModTime().UnixNano()/1000000)
+	var photo *Photo
+	err = c.runStage(ctx, StageEnableUploadedFile, retry, func() (err error) {
+		photo, err = c.Commit(ctx, st.transfer, fileInfo.ModTime())
+		return err
+	})
 	if err != nil {
 		log.Error("Failed to enable upload url, got error %s", err.Error())
-		return nil, err
-	}
-	fmt.Println("photoID:", photoID)
-
-	if album == "" {
-		album = DefaultAlbum
+		return st.fail(StageEnableUploadedFile, err)
 	}
 
-	photo, err := c.moveToAlbum(album, photoID)
-	if err != nil {
-		log.Error("Failed to move the photo to album, got error %s", err.Error())
-		return nil, err
-	}
+	photo.ContentType = contentType
+	st.photo = photo
+	return nil
+}
 
-	photo.Name = filename
-	photo.URL = photoURL
-	return photo, nil
+// CheckSession checks the cookies still give access to google photo.
+func (c *Client) CheckSession() error {
+	return c.parseMagicToken()
 }
 
 //parseMagicToken get the at token ( a magic token ) then set it as the magicToken
@@ -225,7 +267,7 @@ func (c *Client) parseMagicToken() error {
 	}
 
 	if res.StatusCode > 299 {
-		return errors.New(res.Status)
+		return &StatusError{StatusCode: res.StatusCode, Status: res.Status}
 	}
 
 	doc, _ := goquery.NewDocumentFromReader(res.Body)
@@ -241,12 +283,13 @@ func (c *Client) parseMagicToken() error {
 }
 
 //createUploadURL create an new upload url
-func (c *Client) createUploadURL(fileName string, fileSize int64) (string, error) {
+func (c *Client) createUploadURL(ctx context.Context, fileName string, fileSize int64) (string, error) {
 	log.Info("Request to create a new upload url")
 
 	body := NewJSONBody(NewUploadSessionRequest(fileName, fileSize))
 
 	req, _ := http.NewRequest(http.MethodPost, GooglePhotoRequestUploadURL, body)
+	req = req.WithContext(ctx)
 	req.Header.Add("content-type", "application/x-www-form-urlencoded;charset=UTF-8")
 	req.Header.Add("user-agent", ChromeUserAgent)
 
@@ -257,7 +300,7 @@ func (c *Client) createUploadURL(fileName string, fileSize int64) (string, error
 	defer resp.Body.Close()
 
 	if resp.StatusCode > 299 {
-		return "", fmt.Errorf("Failed to create a new upload's id, got error %s", BodyToString(resp.Body))
+		return "", &StatusError{StatusCode: resp.StatusCode, Status: fmt.Sprintf("Failed to create a new upload's id, got error %s", BodyToString(resp.Body))}
 	}
 
 	result := NewSessionUploadFromJson(BodyToString(resp.Body))
@@ -269,16 +312,17 @@ func (c *Client) createUploadURL(fileName string, fileSize int64) (string, error
 }
 
 // upload uploads file to server then you will get a upload token
-func (c *Client) upload(uploadURL string, file io.ReadCloser, fileSize int64, progressHandler ProgressHandler) (string, error) {
+func (c *Client) upload(ctx context.Context, uploadURL string, file io.Reader, fileSize int64, progressHandler ProgressHandler) (string, error) {
 	log.Info("Request to upload file data")
 
-	resp, err := c.uploader.Do(uploadURL, file, fileSize, progressHandler)
+	resp, err := c.uploader.DoContext(ctx, uploadURL, file, fileSize, progressHandler)
 	if err != nil {
 		return "", err
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode > 299 {
-		return "", fmt.Errorf("Failed to upload file, got error %s", BodyToString(resp.Body))
+		return "", &StatusError{StatusCode: resp.StatusCode, Status: fmt.Sprintf("Failed to upload file, got error %s", BodyToString(resp.Body))}
 	}
 
 	stringBody := BodyToString(resp.Body)
@@ -290,13 +334,13 @@ func (c *Client) upload(uploadURL string, file io.ReadCloser, fileSize int64, pr
 	return uploadToken, nil
 }
 
-func (c *Client) enableUploadedFile(uploadBase64Token, fileName string, fileModAt int64) (string, string, error) {
+func (c *Client) enableUploadedFile(uploadBase64Token, fileName string, fileModAt int64) (*Photo, error) {
 	log.Info("Request to enable the uploaded photo %d", fileModAt)
 	query := fmt.Sprintf(`[[["mdpdU","[[[\"%s\",\"%s\",%d]]]",null,"generic"]]]`, uploadBase64Token, fileName, fileModAt)
 	body, err := c.DoQuery(GoogleCommandDataURL, query)
 	if err != nil {
 		log.Error(err)
-		return "", "", err
+		return nil, err
 	}
 	defer body.Close()
 
@@ -304,18 +348,13 @@ func (c *Client) enableUploadedFile(uploadBase64Token, fileName string, fileModA
 	s = regex2.FindString(s)
 	log.Debug(s)
 	var enableImage EnableImageResponse
-	photoURL, err := enableImage.getEnabledImageURL(s)
+	photo, err := enableImage.getEnabledImage(s)
 	if err != nil {
 		log.Error(err)
-		return "", "", err
-	}
-	photoID, err := enableImage.getEnabledImageID(s)
-	if err != nil {
-		log.Error(err)
-		return "", "", err
+		return nil, err
 	}
 
-	return photoID, photoURL, nil
+	return photo, nil
 }
 
 // DoQuery executes http request
@@ -332,17 +371,58 @@ func (client *Client) DoQuery(endpoint string, query string) (io.ReadCloser, err
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded;charset=UTF-8")
 	req.Header.Add("User-Agent", ChromeUserAgent)
 	req.Header.Add("referer", "https://photos.google.com/")
+
+	client.metrics.Add(MetricRPCCalls+queryRPCID(query), 1)
 	res, err := client.hClient.Do(req)
 	if err != nil {
+		client.observeError(err)
 		return nil, err
 	}
 	if res.StatusCode > 299 {
-		return nil, errors.New(res.Status)
+		res.Body.Close()
+		err := &StatusError{StatusCode: res.StatusCode, Status: res.Status}
+		client.observeError(err)
+		return nil, err
 	}
 
 	return res.Body, nil
 }
 
+// doRPC executes a batchexecute rpc then returns the response line of the rpc
+func (c *Client) doRPC(rpcID string, args interface{}) (string, error) {
+	if c.magicToken == "" {
+		if err := c.parseMagicToken(); err != nil {
+			return "", err
+		}
+	}
+
+	body, err := c.DoQuery(GoogleCommandDataURL+"&rpcids="+rpcID, NewRPCQuery(rpcID, args))
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	s := rpcResponseRegex(rpcID).FindString(BodyToString(body))
+	log.Debug(s)
+	if s == "" {
+		return "", fmt.Errorf("Empty response of %s", rpcID)
+	}
+	return s, nil
+}
+
+// rpcResponseRegex returns the regex matching the response line of an rpc, compiled once per rpcid
+func rpcResponseRegex(rpcID string) *regexp.Regexp {
+	rpcRegexMu.Lock()
+	defer rpcRegexMu.Unlock()
+
+	regex, ok := rpcRegexes[rpcID]
+	if !ok {
+		regex = regexp.MustCompile(`\n\[\["wrb.fr","` + regexp.QuoteMeta(rpcID) + `",(.*?)\]\]\n`)
+		rpcRegexes[rpcID] = regex
+	}
+	return regex
+}
+
 // GetAlbums gets all google photo albums
 func (client *Client) GetAlbums() (Albums, error) {
 	log.Info("Request to get albums")
@@ -362,6 +442,7 @@ func (client *Client) GetAlbums() (Albums, error) {
 		return nil, err
 	}
 
+	client.setAlbumCache(albums)
 	return albums, nil
 }
 
@@ -421,12 +502,20 @@ func (c *Client) CreateAlbum(albumName string) (*Album, error) {
 		Name: albumName,
 	}
 
+	c.addToAlbumCache(album)
 	return album, nil
 }
 
-// GetSharedAlbumKey gets an album's share key
+// GetSharedAlbumKey gets an album's share key by scraping the album page.
+// Prefer GetShareInfo, which asks the key through the rpc.
 func (c *Client) GetSharedAlbumKey(albumID string) string {
-	res, _ := c.hClient.Get(fmt.Sprintf("https://photos.google.com/u/0/album/%s", albumID))
+	res, err := c.hClient.Get(fmt.Sprintf("https://photos.google.com/u/0/album/%s", albumID))
+	if err != nil {
+		log.Error("Failed to get the album page %s", err.Error())
+		return ""
+	}
+	defer res.Body.Close()
+
 	if res.StatusCode != 200 {
 		return ""
 	}
@@ -448,57 +537,119 @@ func (c *Client) GetSharedAlbumKey(albumID string) string {
 
 // AddPhotoToAlbum adds a photo to an album
 func (c *Client) AddPhotoToAlbum(albumID, photoID string) error {
-	log.Info("Request to add photo %s to album %s", photoID, albumID)
-	sharedAlbumKey := c.GetSharedAlbumKey(albumID)
+	return c.AddPhotosToAlbum(albumID, []string{photoID})
+}
 
-	var query string
+// AddPhotosToAlbum adds photos to an album.
+// The photos are sent by chunks of AlbumBatchSize, and the share key of the album is looked up once.
+func (c *Client) AddPhotosToAlbum(albumID string, photoIDs []string) error {
+	log.Info("Request to add %d photos to album %s", len(photoIDs), albumID)
+	if len(photoIDs) == 0 {
+		return nil
+	}
 
-	if len(sharedAlbumKey) == 0 {
-		query = fmt.Sprintf(`[[["E1Cajb","[[\"%s\"],\"%s\"]",null,"generic"]]]`, photoID, albumID)
-	} else {
-		query = fmt.Sprintf(`[[["C2V01c","[[\"%s\"],[2,null,[[[\"%s\"]]],null,null,[],[1],null,null,null,[]],\"%s\",[null,null,null,null,[null,[]]]]",null,"generic"]]]`, albumID, photoID, sharedAlbumKey)
+	sharedAlbumKey := c.albumShareKey(albumID)
+	if len(sharedAlbumKey) != 0 {
+		return c.AddPhotosToSharedAlbum(albumID, sharedAlbumKey, photoIDs)
 	}
 
-	body, err := c.DoQuery(GoogleCommandDataURL, query)
-	if err != nil {
-		return err
+	for _, chunk := range chunkIDs(photoIDs, AlbumBatchSize) {
+		body, err := c.DoQuery(GoogleCommandDataURL, NewAddToAlbumQuery(albumID, chunk))
+		if err != nil {
+			return err
+		}
+		body.Close()
+		c.addAlbumItemCount(albumID, len(chunk))
 	}
-	defer body.Close()
 
 	return nil
 }
 
-// RemoveFromAlbum Remove a photo from an album
-func (c *Client) RemoveFromAlbum(photoID string) error {
-	log.Info("Request to remove photo %s from the relevant album", photoID)
-
-	query := NewMutateQuery(
-		QueryNumberRemovePhotoFromAlbum,
-		[]interface{}{
-			[]string{photoID},
-			[]interface{}{},
-		},
-	)
+// albumShareKey returns the share key of an album, empty when the album isn't shared.
+// The cached albums answer without request when they know the album. Otherwise the key is got by GetShareInfo,
+// and a failed lookup is handled like a not shared album.
+func (c *Client) albumShareKey(albumID string) string {
+	if album := c.cachedAlbum(albumID); album != nil && (!album.Shared || album.ShareKey != "") {
+		return album.ShareKey
+	}
 
-	body, err := c.DoQuery(GooglePhotoMutateQueryURL, query)
+	info, err := c.GetShareInfo(albumID)
 	if err != nil {
-		return err
+		log.Warn("Failed to get the share key of album %s, got error %s", albumID, err.Error())
+		return ""
 	}
-	defer body.Close()
+	if info == nil {
+		return ""
+	}
+	return info.Key
+}
+
+// AddPhotoToSharedAlbum adds a photo to a shared album, including the albums shared by other people
+func (c *Client) AddPhotoToSharedAlbum(albumID, shareKey, photoID string) error {
+	return c.AddPhotosToSharedAlbum(albumID, shareKey, []string{photoID})
+}
+
+// AddPhotosToSharedAlbum adds photos to a shared album, by chunks of AlbumBatchSize
+func (c *Client) AddPhotosToSharedAlbum(albumID, shareKey string, photoIDs []string) error {
+	log.Info("Request to add %d photos to shared album %s", len(photoIDs), albumID)
+
+	for _, chunk := range chunkIDs(photoIDs, AlbumBatchSize) {
+		body, err := c.DoQuery(GoogleCommandDataURL, NewAddToSharedAlbumQuery(albumID, shareKey, chunk))
+		if err != nil {
+			return err
+		}
+		body.Close()
+		c.addAlbumItemCount(albumID, len(chunk))
+	}
+
 	return nil
 }
 
+// RemoveFromAlbum removes photos from an album, by chunks of AlbumBatchSize.
+// It returns the IDs of the photos which were actually removed, the photos that weren't in the album are left out.
+// When a chunk fails, the photos removed by the chunks before are returned with the error.
+func (c *Client) RemoveFromAlbum(albumID string, photoIDs ...string) ([]string, error) {
+	log.Info("Request to remove %d photos from album %s", len(photoIDs), albumID)
+
+	removed := []string{}
+	// The cached item count follows what was removed, even if a chunk fails
+	defer c.decreaseAlbumItemCount(albumID, &removed)
+
+	for _, chunk := range chunkIDs(photoIDs, AlbumBatchSize) {
+		s, err := c.doRPC(QueryStringRemoveFromAlbum, []interface{}{chunk, albumID})
+		if err != nil {
+			return removed, err
+		}
+
+		ids, err := NewRemoveFromAlbumResponse(s).Removed()
+		if err != nil {
+			return removed, err
+		}
+		removed = append(removed, ids...)
+	}
+
+	return removed, nil
+}
+
 // moveToAlbum move a photo to an album
 func (c *Client) moveToAlbum(albumName string, photoID string) (*Photo, error) {
 	log.Info("Request to move the upload file to the album %s", albumName)
 
-	albums, err := c.GetAlbums()
+	albums, err := c.CachedAlbums()
 	if err != nil {
 		log.Error("Failed to get album %s", err.Error())
 		return nil, err
 	}
 
+	// The cache may miss an album created elsewhere since it was fetched, refresh it before creating a duplicate
 	album := albums.Get(albumName)
+	if album == nil {
+		if albums, err = c.GetAlbums(); err != nil {
+			log.Error("Failed to get album %s", err.Error())
+			return nil, err
+		}
+		album = albums.Get(albumName)
+	}
 	photo := Photo{ID: photoID}
 
 	if album == nil {
@@ -519,6 +670,14 @@ func (c *Client) moveToAlbum(albumName string, photoID string) (*Photo, error) {
 	return &photo, nil
 }
 
-func (c *Client) getPhotoURL(photoID string) (string, error) {
-	return "", nil
+// getPhotoInfo gets the base url, the dimensions, the timestamp and the type of a media item
+func (c *Client) getPhotoInfo(photoID string) (*Photo, error) {
+	log.Info("Request to get the info of photo %s", photoID)
+
+	s, err := c.doRPC(QueryStringGetMediaItem, []interface{}{photoID, nil, nil, 1})
+	if err != nil {
+		return nil, err
+	}
+
+	return NewMediaItemResponse(s).Photo()
 }
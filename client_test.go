@@ -5,9 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
 	"path"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -70,11 +73,14 @@ func TestUpload(t *testing.T) {
 		}
 
 		assert.NotEmpty(t, photo.ID)
-		assert.NotEmpty(t, photo.AlbumID)
+		assert.Empty(t, photo.AlbumID)
 		assert.NotEmpty(t, photo.URL)
 		assert.True(t, strings.HasPrefix(photo.URL, "https://lh3.googleusercontent.com/"))
 		fmt.Println(photo.URL, photo.ID)
 		assert.Equal(t, photo.Name, "sample.mp4")
+		assert.NotZero(t, photo.Size)
+		assert.Equal(t, "video/mp4", photo.ContentType)
+		assert.NotEmpty(t, photo.DedupKey)
 	})
 
 	t.Run("UploadSuccessWithoutProgressHandlerAndFileName", func(t *testing.T) {
@@ -84,7 +90,7 @@ func TestUpload(t *testing.T) {
 		}
 
 		assert.NotEmpty(t, photo.ID)
-		assert.NotEmpty(t, photo.AlbumID)
+		assert.Empty(t, photo.AlbumID)
 		assert.NotEmpty(t, photo.URL)
 		assert.NotEmpty(t, photo.Name)
 		assert.Equal(t, path.Base(sampleFile), photo.Name)
@@ -107,11 +113,27 @@ func TestUpload(t *testing.T) {
 
 		assert.Equal(t, current, total)
 		assert.NotEmpty(t, photo.ID)
-		assert.NotEmpty(t, photo.AlbumID)
+		assert.Empty(t, photo.AlbumID)
 		assert.NotEmpty(t, photo.URL)
 		assert.NotEmpty(t, photo.Name)
 	})
 
+	t.Run("UploadToAlbum", func(t *testing.T) {
+		photo, err := client.Upload(sampleFile, "", "gphoto-test", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.NotEmpty(t, photo.ID)
+		assert.NotEmpty(t, photo.AlbumID)
+
+		photo, err = client.UploadToAlbumID(sampleFile, "", photo.AlbumID, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.NotEmpty(t, photo.AlbumID)
+	})
+
 }
 
 func BenchmarkReUpload(b *testing.B) {
@@ -142,3 +164,68 @@ func TestLogin(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// newFakeClient returns a client whose requests are answered by handle instead of google photo
+func newFakeClient(handle func(req *http.Request) (int, string)) *Client {
+	client := NewClient().SetMetrics(nil).SetHTTPClient(&http.Client{
+		Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			code, body := handle(req)
+			return &http.Response{StatusCode: code, Status: http.StatusText(code), Body: ioutil.NopCloser(strings.NewReader(body))}, nil
+		}),
+	})
+	client.magicToken = "token"
+	return client
+}
+
+// rpcBody formats the batchexecute response of an rpc returning payload
+func rpcBody(rpcID, payload string) string {
+	return ")]}'\n\n120\n" + `[["wrb.fr","` + rpcID + `",` + strconv.Quote(payload) + `,null,null,null,"generic"]]` + "\n55\n[[\"e\",4,null,null,171]]\n"
+}
+
+func TestDoRPC(t *testing.T) {
+	var form url.Values
+	var query url.Values
+	client := newFakeClient(func(req *http.Request) (int, string) {
+		query = req.URL.Query()
+		req.ParseForm()
+		form = req.PostForm
+		switch query.Get("rpcids") {
+		case "fail":
+			return http.StatusInternalServerError, ""
+		case "empty":
+			return http.StatusOK, rpcBody("other", "[]")
+		}
+		return http.StatusOK, rpcBody("other", "[1]") + rpcBody("lcxiM", `[["photo"]]`)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		s, err := client.doRPC("lcxiM", []interface{}{nil, 1})
+		require.NoError(t, err)
+		assert.Equal(t, "lcxiM", query.Get("rpcids"))
+		assert.Equal(t, "token", form.Get("at"))
+		assert.Equal(t, NewRPCQuery("lcxiM", []interface{}{nil, 1}), form.Get("f.req"))
+		assert.Equal(t, []interface{}{[]interface{}{"photo"}}, getRPCPayload(s))
+	})
+
+	t.Run("EmptyResponse", func(t *testing.T) {
+		_, err := client.doRPC("empty", nil)
+		assert.EqualError(t, err, "Empty response of empty")
+	})
+
+	t.Run("StatusError", func(t *testing.T) {
+		_, err := client.doRPC("fail", nil)
+		require.IsType(t, &StatusError{}, err)
+		assert.Equal(t, http.StatusInternalServerError, err.(*StatusError).StatusCode)
+	})
+
+	t.Run("RegexCompiledOnce", func(t *testing.T) {
+		assert.True(t, rpcResponseRegex("lcxiM") == rpcResponseRegex("lcxiM"))
+		assert.False(t, rpcResponseRegex("a.c").MatchString("\n[[\"wrb.fr\",\"abc\",null]]\n"))
+	})
+}
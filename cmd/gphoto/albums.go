@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/canhlinh/gphoto"
+)
+
+const albumsUsage = "usage: gphoto albums list | gphoto albums create name"
+
+type albumResult struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	ItemCount  int    `json:"item_count"`
+	Shared     bool   `json:"shared"`
+	ShareKey   string `json:"share_key,omitempty"`
+	CoverURL   string `json:"cover_url,omitempty"`
+	StartTime  string `json:"start_time,omitempty"`
+	EndTime    string `json:"end_time,omitempty"`
+	CreatedAt  string `json:"created_at,omitempty"`
+	ModifiedAt string `json:"modified_at,omitempty"`
+}
+
+func newAlbumResult(album *gphoto.Album) *albumResult {
+	result := &albumResult{
+		ID:         album.ID,
+		Name:       album.Name,
+		ItemCount:  album.ItemCount,
+		Shared:     album.Shared,
+		ShareKey:   album.ShareKey,
+		StartTime:  formatTime(album.StartTime),
+		EndTime:    formatTime(album.EndTime),
+		CreatedAt:  formatTime(album.CreatedAt),
+		ModifiedAt: formatTime(album.ModifiedAt),
+	}
+	if album.Cover != nil {
+		result.CoverURL = album.Cover.URL
+	}
+	return result
+}
+
+func runAlbums(args []string) error {
+	if len(args) == 0 {
+		return errors.New(albumsUsage)
+	}
+
+	switch args[0] {
+	case "list":
+		return runAlbumsList(args[1:])
+	case "create":
+		return runAlbumsCreate(args[1:])
+	}
+	return fmt.Errorf("unknown albums command %q\n%s", args[0], albumsUsage)
+}
+
+func runAlbumsList(args []string) error {
+	fs, o := newFlagSet("albums list")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	p, err := o.printer()
+	if err != nil {
+		return err
+	}
+	client, err := o.newClient()
+	if err != nil {
+		return err
+	}
+
+	albums, err := client.GetAlbums()
+	if err != nil {
+		return err
+	}
+	if albums == nil {
+		albums = gphoto.Albums{}
+	}
+	return printAlbums(p, albums)
+}
+
+func runAlbumsCreate(args []string) error {
+	fs, o := newFlagSet("albums create")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: gphoto albums create name")
+	}
+
+	p, err := o.printer()
+	if err != nil {
+		return err
+	}
+	client, err := o.newClient()
+	if err != nil {
+		return err
+	}
+
+	album, err := client.CreateAlbum(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	return printAlbums(p, gphoto.Albums{album})
+}
+
+func printAlbums(p *printer, albums gphoto.Albums) error {
+	results := []*albumResult{}
+	var rows [][]string
+	for _, album := range albums {
+		result := newAlbumResult(album)
+		results = append(results, result)
+		rows = append(rows, []string{result.ID, result.Name, strconv.Itoa(result.ItemCount), strconv.FormatBool(result.Shared)})
+	}
+	return p.print(results, []string{"ID", "NAME", "ITEMS", "SHARED"}, rows)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// loadCookies reads the cookies from path, or from GPHOTO_COOKIES_BASE64 when path is empty.
+// The JSON format is the one exported by the EditThisCookie extension.
+func loadCookies(path string) ([]*http.Cookie, error) {
+	var cookies []*http.Cookie
+
+	if path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		if err := json.NewDecoder(file).Decode(&cookies); err != nil {
+			return nil, fmt.Errorf("invalid cookies file %s: %v", path, err)
+		}
+		return cookies, nil
+	}
+
+	encoded := os.Getenv("GPHOTO_COOKIES_BASE64")
+	if encoded == "" {
+		return nil, errors.New("no cookies, use -cookies or set GPHOTO_COOKIES_FILE or GPHOTO_COOKIES_BASE64")
+	}
+
+	data, err := base64.URLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GPHOTO_COOKIES_BASE64: %v", err)
+	}
+	if err := json.Unmarshal(data, &cookies); err != nil {
+		return nil, fmt.Errorf("invalid GPHOTO_COOKIES_BASE64: %v", err)
+	}
+	return cookies, nil
+}
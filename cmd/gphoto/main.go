@@ -0,0 +1,136 @@
+// Command gphoto uploads files to google photo and manages albums from the shell.
+//
+// Usage:
+//
+//	gphoto upload [-album name | -album-id id | -share-url link] [-name filename] [-description text] [-skip-existing] [-retries n] [-check-quota] files or globs...
+//	gphoto albums list
+//	gphoto albums create name
+//	gphoto quota
+//	gphoto search [-limit n] query
+//	gphoto sync [-album name] [-state file] [-dry-run] [-check-quota] dir
+//	gphoto watch [-album name] [-done dir] [-queue file] [-interval d] [-settle d] dirs...
+//	gphoto session check
+//
+// Cookies are read from the file given by -cookies, from the file named by
+// GPHOTO_COOKIES_FILE, or from the base64 encoded JSON in GPHOTO_COOKIES_BASE64.
+// Every command prints a table by default, or JSON with -output json.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/canhlinh/gphoto"
+	log "github.com/canhlinh/log4go"
+)
+
+const usageText = `Usage: gphoto <command> [flags] [args]
+
+Commands:
+  upload [-album name | -album-id id | -share-url link] [-name filename] [-description text]
+         [-skip-existing] [-retries n] [-check-quota] files...
+                                          upload files or globs
+  albums list                             list albums
+  albums create name                      create an album
+  quota                                   show the storage usage
+  search [-limit n] query                 search the library
+  sync [-album name] [-state file] [-dry-run] [-check-quota] dir
+                                          upload the new or changed files of dir
+  watch [-album name] [-done dir] [-queue file] [-interval d] [-settle d] dirs...
+                                          upload the files appearing in dirs until stopped
+  session check                           check the cookies are still valid
+
+Common flags:
+  -cookies file     cookies JSON file (default $GPHOTO_COOKIES_FILE)
+  -output format    table or json (default table)
+  -v                log requests to stderr
+
+Run "gphoto <command> -h" for the flags of a command.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usageText)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "upload":
+		err = runUpload(os.Args[2:])
+	case "albums":
+		err = runAlbums(os.Args[2:])
+	case "quota":
+		err = runQuota(os.Args[2:])
+	case "search":
+		err = runSearch(os.Args[2:])
+	case "sync":
+		err = runSync(os.Args[2:])
+	case "watch":
+		err = runWatch(os.Args[2:])
+	case "session":
+		err = runSession(os.Args[2:])
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usageText)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "gphoto: unknown command %q\n\n%s", os.Args[1], usageText)
+		os.Exit(2)
+	}
+
+	if err == flag.ErrHelp {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gphoto:", err)
+		os.Exit(1)
+	}
+}
+
+// options are the flags shared by every command
+type options struct {
+	cookies string
+	output  string
+	verbose bool
+}
+
+// newFlagSet creates a flag set with the common flags registered
+func newFlagSet(name string) (*flag.FlagSet, *options) {
+	o := &options{}
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&o.cookies, "cookies", os.Getenv("GPHOTO_COOKIES_FILE"), "cookies JSON file")
+	fs.StringVar(&o.output, "output", "table", "output format: table or json")
+	fs.BoolVar(&o.verbose, "v", false, "log requests to stderr")
+	return fs, o
+}
+
+// newClient validates the common flags and returns a client with a live session
+func (o *options) newClient() (*gphoto.Client, error) {
+	if _, err := o.printer(); err != nil {
+		return nil, err
+	}
+
+	// The library logs to stdout, which would mix with the command output.
+	level := log.WARNING
+	if o.verbose {
+		level = log.INFO
+	}
+	log.Global.Close()
+	log.Global.AddFilter("stderr", level, log.NewFormatLogWriter(os.Stderr, log.FORMAT_SHORT+"\n"))
+
+	cookies, err := loadCookies(o.cookies)
+	if err != nil {
+		return nil, err
+	}
+
+	client := gphoto.NewClient(cookies...)
+	if err := client.CheckSession(); err != nil {
+		return nil, fmt.Errorf("invalid session: %v", err)
+	}
+	return client, nil
+}
+
+func (o *options) printer() (*printer, error) {
+	return newPrinter(os.Stdout, o.output)
+}
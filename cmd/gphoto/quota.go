@@ -0,0 +1,71 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/canhlinh/gphoto"
+)
+
+type quotaResult struct {
+	Used                 int64 `json:"used"`
+	Limit                int64 `json:"limit"`
+	Remaining            int64 `json:"remaining"`
+	CountsAgainstStorage bool  `json:"counts_against_storage"`
+}
+
+func runQuota(args []string) error {
+	fs, o := newFlagSet("quota")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	p, err := o.printer()
+	if err != nil {
+		return err
+	}
+	client, err := o.newClient()
+	if err != nil {
+		return err
+	}
+
+	quota, err := client.Quota()
+	if err != nil {
+		return err
+	}
+
+	result := &quotaResult{
+		Used:                 quota.Used,
+		Limit:                quota.Limit,
+		Remaining:            quota.Remaining(),
+		CountsAgainstStorage: quota.CountsAgainstStorage,
+	}
+	row := []string{
+		strconv.FormatInt(result.Used, 10),
+		strconv.FormatInt(result.Limit, 10),
+		strconv.FormatInt(result.Remaining, 10),
+		strconv.FormatBool(result.CountsAgainstStorage),
+	}
+	return p.print(result, []string{"USED", "LIMIT", "REMAINING", "COUNTS_AGAINST_STORAGE"}, [][]string{row})
+}
+
+// checkQuota fails when the files don't fit in the storage left
+func checkQuota(client *gphoto.Client, files []string) error {
+	quota, err := client.Quota()
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	for _, file := range files {
+		size, err := fileSize(file)
+		if err != nil {
+			return err
+		}
+		total += size
+	}
+
+	if !quota.Fits(total) {
+		return gphoto.ErrorQuotaExceeded
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/canhlinh/gphoto"
+)
+
+type photoResult struct {
+	ID        string `json:"id"`
+	AlbumID   string `json:"album_id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	MediaType string `json:"media_type,omitempty"`
+	Timestamp string `json:"timestamp,omitempty"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	URL       string `json:"url,omitempty"`
+}
+
+func newPhotoResult(photo *gphoto.Photo) *photoResult {
+	return &photoResult{
+		ID:        photo.ID,
+		AlbumID:   photo.AlbumID,
+		Name:      photo.Name,
+		MediaType: string(photo.MediaType),
+		Timestamp: formatTime(photo.Timestamp),
+		Width:     photo.Width,
+		Height:    photo.Height,
+		URL:       photo.URL,
+	}
+}
+
+func runSearch(args []string) error {
+	fs, o := newFlagSet("search")
+	limit := fs.Int("limit", 100, "maximum number of results, 0 for all")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("usage: gphoto search [-limit n] query")
+	}
+
+	p, err := o.printer()
+	if err != nil {
+		return err
+	}
+	client, err := o.newClient()
+	if err != nil {
+		return err
+	}
+
+	photos := []*photoResult{}
+	it := client.Search(strings.Join(fs.Args(), " "))
+	for *limit == 0 || len(photos) < *limit {
+		photo, err := it.Next()
+		if err == gphoto.ErrorIteratorDone {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		photos = append(photos, newPhotoResult(photo))
+	}
+
+	var rows [][]string
+	for _, photo := range photos {
+		rows = append(rows, []string{
+			photo.ID,
+			photo.MediaType,
+			photo.Timestamp,
+			strconv.Itoa(photo.Width) + "x" + strconv.Itoa(photo.Height),
+			photo.URL,
+		})
+	}
+	return p.print(photos, []string{"ID", "TYPE", "TIMESTAMP", "SIZE", "URL"}, rows)
+}
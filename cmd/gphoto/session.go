@@ -0,0 +1,43 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+type sessionResult struct {
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+}
+
+func runSession(args []string) error {
+	if len(args) == 0 || args[0] != "check" {
+		return errors.New("usage: gphoto session check")
+	}
+
+	fs, o := newFlagSet("session check")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	p, err := o.printer()
+	if err != nil {
+		return err
+	}
+
+	result := &sessionResult{Valid: true}
+	if _, err := o.newClient(); err != nil {
+		result.Valid = false
+		result.Error = err.Error()
+	}
+
+	row := []string{fmt.Sprint(result.Valid), result.Error}
+	if err := p.print(result, []string{"VALID", "ERROR"}, [][]string{row}); err != nil {
+		return err
+	}
+
+	if !result.Valid {
+		return errors.New("session is not valid")
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/canhlinh/gphoto"
+)
+
+type syncFileResult struct {
+	File  string `json:"file"`
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+type syncResult struct {
+	Uploaded []*syncFileResult `json:"uploaded"`
+	Failed   []*syncFileResult `json:"failed"`
+	Skipped  int               `json:"skipped"`
+}
+
+func runSync(args []string) error {
+	fs, o := newFlagSet("sync")
+	album := fs.String("album", "", "album name, created if it doesn't exist")
+	state := fs.String("state", "", "state file (default <dir>/"+gphoto.DefaultSyncStateFile+")")
+	dryRun := fs.Bool("dry-run", false, "list the files to upload without uploading them")
+	checkQuota := fs.Bool("check-quota", false, "stop before a file that doesn't fit in the storage left")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: gphoto sync [-album name] [-state file] [-dry-run] [-check-quota] dir")
+	}
+
+	p, err := o.printer()
+	if err != nil {
+		return err
+	}
+	client, err := o.newClient()
+	if err != nil {
+		return err
+	}
+
+	res, syncErr := client.SyncDir(fs.Arg(0), *album, &gphoto.SyncOptions{StateFile: *state, DryRun: *dryRun, CheckQuota: *checkQuota})
+	if res == nil {
+		return syncErr
+	}
+
+	result := &syncResult{
+		Uploaded: []*syncFileResult{},
+		Failed:   []*syncFileResult{},
+		Skipped:  res.Skipped,
+	}
+	for file, photo := range res.Uploaded {
+		r := &syncFileResult{File: file}
+		if photo != nil {
+			r.ID = photo.ID
+		}
+		result.Uploaded = append(result.Uploaded, r)
+	}
+	for file, err := range res.Failed {
+		result.Failed = append(result.Failed, &syncFileResult{File: file, Error: err.Error()})
+	}
+	sort.Slice(result.Uploaded, func(i, j int) bool { return result.Uploaded[i].File < result.Uploaded[j].File })
+	sort.Slice(result.Failed, func(i, j int) bool { return result.Failed[i].File < result.Failed[j].File })
+
+	var rows [][]string
+	for _, r := range result.Uploaded {
+		status := "uploaded"
+		if *dryRun {
+			status = "pending"
+		}
+		rows = append(rows, []string{r.File, status, r.ID})
+	}
+	for _, r := range result.Failed {
+		rows = append(rows, []string{r.File, "failed", r.Error})
+	}
+	if err := p.print(result, []string{"FILE", "STATUS", "ID"}, rows); err != nil {
+		return err
+	}
+
+	if syncErr != nil {
+		return syncErr
+	}
+	if len(result.Failed) > 0 {
+		return fmt.Errorf("%d files failed to sync", len(result.Failed))
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/canhlinh/gphoto"
+)
+
+type uploadResult struct {
+	File    string `json:"file"`
+	ID      string `json:"id,omitempty"`
+	AlbumID string `json:"album_id,omitempty"`
+	URL     string `json:"url,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+func runUpload(args []string) error {
+	fs, o := newFlagSet("upload")
+	album := fs.String("album", "", "album name, created if it doesn't exist")
+	albumID := fs.String("album-id", "", "album ID")
+	name := fs.String("name", "", "file name shown in google photo, only with a single file")
+	shareURL := fs.String("share-url", "", "join the shared album of this link and upload into it")
+	checkQuotaFirst := fs.Bool("check-quota", false, "fail before uploading if the files don't fit in the storage left")
+	description := fs.String("description", "", "description of the uploaded files")
+	skipExisting := fs.Bool("skip-existing", false, "don't upload the files the library already has")
+	retries := fs.Int("retries", 0, "number of times a failed upload step is tried again")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	files, err := expandFiles(fs.Args())
+	if err != nil {
+		return err
+	}
+	if *name != "" && len(files) > 1 {
+		return errors.New("-name can only be used with a single file")
+	}
+	if countSet(*album, *albumID, *shareURL) > 1 {
+		return errors.New("-album, -album-id and -share-url can't be used together")
+	}
+
+	p, err := o.printer()
+	if err != nil {
+		return err
+	}
+	client, err := o.newClient()
+	if err != nil {
+		return err
+	}
+	if *checkQuotaFirst {
+		if err := checkQuota(client, files); err != nil {
+			return err
+		}
+	}
+
+	opts := []gphoto.UploadOption{gphoto.WithFilename(*name), gphoto.WithAlbum(*album), gphoto.WithDescription(*description)}
+	if *albumID != "" {
+		opts = append(opts, gphoto.WithAlbumID(*albumID))
+	}
+	if *shareURL != "" {
+		sharedAlbum, err := client.JoinSharedAlbum(*shareURL)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, gphoto.WithSharedAlbum(sharedAlbum))
+	}
+	if *skipExisting {
+		opts = append(opts, gphoto.WithDedup(gphoto.DedupSkip))
+	}
+	if *retries > 0 {
+		policy := gphoto.DefaultRetryPolicy
+		policy.MaxAttempts = *retries + 1
+		opts = append(opts, gphoto.WithRetry(policy))
+	}
+	upload := func(file string) (*gphoto.Photo, error) {
+		return client.UploadFile(context.Background(), file, opts...)
+	}
+
+	var results []*uploadResult
+	var failed int
+	for _, file := range files {
+		result := &uploadResult{File: file}
+		// The photo is kept even if a later stage failed, so the output tells it's in the library
+		photo, err := upload(file)
+		if photo != nil {
+			result.ID = photo.ID
+			result.AlbumID = photo.AlbumID
+			result.URL = photo.URL
+		}
+		if err != nil {
+			result.Error = err.Error()
+			failed++
+		}
+		results = append(results, result)
+	}
+
+	var rows [][]string
+	for _, r := range results {
+		rows = append(rows, []string{r.File, r.ID, r.AlbumID, r.URL, r.Error})
+	}
+	if err := p.print(results, []string{"FILE", "ID", "ALBUM", "URL", "ERROR"}, rows); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d uploads failed", failed, len(files))
+	}
+	return nil
+}
+
+// expandFiles expands the glob patterns in args, keeping regular files only
+func expandFiles(args []string) ([]string, error) {
+	if len(args) == 0 {
+		return nil, errors.New("no file to upload")
+	}
+
+	var files []string
+	for _, arg := range args {
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("%s: no such file", arg)
+		}
+
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return nil, err
+			}
+			if info.Mode().IsRegular() {
+				files = append(files, match)
+			}
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, errors.New("no file to upload")
+	}
+	return files, nil
+}
+
+func fileSize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// countSet counts the non empty values
+func countSet(values ...string) int {
+	var n int
+	for _, v := range values {
+		if v != "" {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/canhlinh/gphoto"
+)
+
+func runWatch(args []string) error {
+	fs, o := newFlagSet("watch")
+	album := fs.String("album", "", "album name, created if it doesn't exist")
+	done := fs.String("done", "", "move the uploaded files to this directory")
+	queue := fs.String("queue", "", "queue file (default <first dir>/"+gphoto.DefaultWatchQueueFile+")")
+	interval := fs.Duration("interval", gphoto.DefaultWatchPollInterval, "how often the directories are scanned")
+	settle := fs.Duration("settle", gphoto.DefaultWatchSettleTime, "how long a file must stop growing before it's uploaded")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("usage: gphoto watch [-album name] [-done dir] [-queue file] [-interval d] [-settle d] dirs...")
+	}
+
+	p, err := o.printer()
+	if err != nil {
+		return err
+	}
+	client, err := o.newClient()
+	if err != nil {
+		return err
+	}
+
+	watcher, err := client.NewWatcher(gphoto.WatchOptions{
+		Dirs:         fs.Args(),
+		Album:        *album,
+		DoneDir:      *done,
+		QueueFile:    *queue,
+		PollInterval: *interval,
+		SettleTime:   *settle,
+		OnUpload: func(path string, photo *gphoto.Photo, err error) {
+			result := &uploadResult{File: path}
+			if err != nil {
+				result.Error = err.Error()
+			} else {
+				result.ID = photo.ID
+				result.AlbumID = photo.AlbumID
+				result.URL = photo.URL
+			}
+			p.event(result, []string{result.File, result.ID, result.AlbumID, result.URL, result.Error})
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		cancel()
+	}()
+
+	if err := watcher.Run(ctx); err != context.Canceled {
+		return err
+	}
+	return nil
+}
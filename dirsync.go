@@ -0,0 +1,252 @@
+package gphoto
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/canhlinh/log4go"
+)
+
+// DefaultSyncStateFile the state file SyncDir keeps in the synced directory when no other is given
+const DefaultSyncStateFile = ".gphoto-sync.json"
+
+// SyncOptions tunes SyncDir
+type SyncOptions struct {
+	// StateFile is where the uploaded files are recorded. Default is DefaultSyncStateFile in the synced directory.
+	StateFile string
+	// DryRun lists the files that would be uploaded without uploading them
+	DryRun bool
+	// CheckQuota stops the sync with ErrorQuotaExceeded before a file that doesn't fit in the storage left
+	CheckQuota bool
+}
+
+// SyncEntry records an uploaded file
+type SyncEntry struct {
+	Size       int64     `json:"size"`
+	ModTime    time.Time `json:"mod_time"`
+	PhotoID    string    `json:"photo_id"`
+	AlbumID    string    `json:"album_id"`
+	UploadedAt time.Time `json:"uploaded_at"`
+	// Incomplete the file was committed as PhotoID but a later stage failed, the next sync runs the stages left only
+	Incomplete bool `json:"incomplete,omitempty"`
+}
+
+// SyncState is the list of files a directory sync already uploaded, keyed by their slash separated path relative to the directory
+type SyncState struct {
+	Files map[string]*SyncEntry `json:"files"`
+
+	mu   sync.Mutex
+	path string
+}
+
+// LoadSyncState reads the state file at path. A missing file gives an empty state.
+func LoadSyncState(path string) (*SyncState, error) {
+	state := &SyncState{
+		Files: map[string]*SyncEntry{},
+		path:  path,
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, err
+	}
+	if state.Files == nil {
+		state.Files = map[string]*SyncEntry{}
+	}
+	return state, nil
+}
+
+// Save writes the state back to its file
+func (s *SyncState) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return WriteJSONFile(s.path, s)
+}
+
+// Changed reports whether the file is new or differs from the uploaded one
+func (s *SyncState) Changed(rel string, info os.FileInfo) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.Files[rel]
+	if !ok || entry.Incomplete {
+		return true
+	}
+	return entry.Size != info.Size() || !entry.ModTime.Equal(info.ModTime())
+}
+
+// incompletePhotoID returns the media item the unchanged file was committed as by an incomplete upload, or ""
+func (s *SyncState) incompletePhotoID(rel string, info os.FileInfo) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.Files[rel]
+	if !ok || !entry.Incomplete || entry.Size != info.Size() || !entry.ModTime.Equal(info.ModTime()) {
+		return ""
+	}
+	return entry.PhotoID
+}
+
+// Record marks the file as uploaded
+func (s *SyncState) Record(rel string, info os.FileInfo, photo *Photo) {
+	s.record(rel, info, photo, false)
+}
+
+// RecordIncomplete marks the file as committed as photo, with some stages of the upload left
+func (s *SyncState) RecordIncomplete(rel string, info os.FileInfo, photo *Photo) {
+	s.record(rel, info, photo, true)
+}
+
+func (s *SyncState) record(rel string, info os.FileInfo, photo *Photo, incomplete bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Files[rel] = &SyncEntry{
+		Size:       info.Size(),
+		ModTime:    info.ModTime(),
+		PhotoID:    photo.ID,
+		AlbumID:    photo.AlbumID,
+		UploadedAt: time.Now(),
+		Incomplete: incomplete,
+	}
+}
+
+// SyncResult summaries a directory sync
+type SyncResult struct {
+	// Uploaded the uploaded photos keyed by their relative path. With DryRun the photos are nil.
+	Uploaded map[string]*Photo
+	// Skipped the number of files already uploaded
+	Skipped int
+	// Failed the upload errors keyed by the relative path
+	Failed map[string]error
+}
+
+// SyncDir uploads the new or changed files of dir to the album, one way.
+// The uploaded files are recorded in a state file, so running it again only uploads what changed since.
+// Hidden files are ignored. A failed upload doesn't stop the sync, it's reported in the result.
+// A file which failed after being committed isn't uploaded again, the next sync only runs the stages left.
+func (c *Client) SyncDir(dir string, album string, opts *SyncOptions) (*SyncResult, error) {
+	return syncDir(dir, opts, func(path string, photoID string) (*Photo, error) {
+		return c.uploadToAlbum(context.Background(), path, photoID, album)
+	}, c.Quota)
+}
+
+// syncDir runs the sync with upload, which resumes the upload of the committed media item photoID when it's given
+func syncDir(dir string, opts *SyncOptions, upload func(path string, photoID string) (*Photo, error), getQuota func() (*Quota, error)) (*SyncResult, error) {
+	log.Info("Request to sync directory %s", dir)
+
+	if opts == nil {
+		opts = &SyncOptions{}
+	}
+	stateFile := opts.StateFile
+	if stateFile == "" {
+		stateFile = filepath.Join(dir, DefaultSyncStateFile)
+	}
+	stateFile, err := filepath.Abs(stateFile)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := LoadSyncState(stateFile)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SyncResult{
+		Uploaded: map[string]*Photo{},
+		Failed:   map[string]error{},
+	}
+
+	// The quota is fetched once, then the sizes of the uploaded files are deducted locally
+	var quota *Quota
+	if opts.CheckQuota {
+		if quota, err = getQuota(); err != nil {
+			return nil, err
+		}
+	}
+
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if strings.HasPrefix(info.Name(), ".") && path != dir {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		if abs, _ := filepath.Abs(path); abs == stateFile || abs == stateFile+".tmp" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if !state.Changed(rel, info) {
+			result.Skipped++
+			return nil
+		}
+
+		// The bytes of a committed file count in the storage already
+		size := info.Size()
+		photoID := state.incompletePhotoID(rel, info)
+		if photoID != "" {
+			size = 0
+		}
+		if quota != nil {
+			if !quota.Fits(size) {
+				log.Warn("Stop syncing before %s, %d bytes left in the storage", rel, quota.Remaining())
+				return ErrorQuotaExceeded
+			}
+		}
+
+		if opts.DryRun {
+			if quota != nil {
+				quota.Used += size
+			}
+			result.Uploaded[rel] = nil
+			return nil
+		}
+
+		photo, err := upload(path, photoID)
+		if err != nil {
+			log.Warn("Failed to sync %s, got error %s", rel, err.Error())
+			result.Failed[rel] = err
+			if committed := committedPhoto(err); committed != nil {
+				state.RecordIncomplete(rel, info, committed)
+				return state.Save()
+			}
+			return nil
+		}
+		result.Uploaded[rel] = photo
+		if quota != nil {
+			quota.Used += size
+		}
+
+		// Save after every upload, an interrupted sync must not upload the same files again
+		state.Record(rel, info, photo)
+		return state.Save()
+	})
+
+	return result, err
+}
@@ -0,0 +1,161 @@
+package gphoto
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gphoto-sync")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "2021", ".thumbnails"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a.jpg"), []byte("a"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "2021", "b.jpg"), []byte("b"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "2021", ".thumbnails", "b.jpg"), []byte("b"), 0644))
+
+	var uploaded []string
+	upload := func(path string, photoID string) (*Photo, error) {
+		uploaded = append(uploaded, path)
+		if filepath.Base(path) == "broken.jpg" {
+			return nil, errors.New("boom")
+		}
+		return &Photo{ID: "id-" + filepath.Base(path), AlbumID: "album"}, nil
+	}
+
+	t.Run("UploadNewFiles", func(t *testing.T) {
+		result, err := syncDir(dir, nil, upload, nil)
+		require.NoError(t, err)
+		assert.Len(t, result.Uploaded, 2)
+		assert.Equal(t, "id-b.jpg", result.Uploaded["2021/b.jpg"].ID)
+		assert.Equal(t, 0, result.Skipped)
+
+		state, err := LoadSyncState(filepath.Join(dir, DefaultSyncStateFile))
+		require.NoError(t, err)
+		assert.Len(t, state.Files, 2)
+		assert.Equal(t, "id-a.jpg", state.Files["a.jpg"].PhotoID)
+	})
+
+	t.Run("SkipUploadedFiles", func(t *testing.T) {
+		uploaded = nil
+		result, err := syncDir(dir, nil, upload, nil)
+		require.NoError(t, err)
+		assert.Empty(t, uploaded)
+		assert.Equal(t, 2, result.Skipped)
+	})
+
+	t.Run("UploadChangedFiles", func(t *testing.T) {
+		uploaded = nil
+		later := time.Now().Add(time.Hour)
+		require.NoError(t, os.Chtimes(filepath.Join(dir, "a.jpg"), later, later))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "broken.jpg"), []byte("c"), 0644))
+
+		result, err := syncDir(dir, nil, upload, nil)
+		require.NoError(t, err)
+		assert.Len(t, uploaded, 2)
+		assert.Contains(t, result.Uploaded, "a.jpg")
+		assert.Contains(t, result.Failed, "broken.jpg")
+		assert.Equal(t, 1, result.Skipped)
+	})
+
+	t.Run("DryRun", func(t *testing.T) {
+		uploaded = nil
+		result, err := syncDir(dir, &SyncOptions{DryRun: true}, upload, nil)
+		require.NoError(t, err)
+		assert.Empty(t, uploaded)
+		assert.Contains(t, result.Uploaded, "broken.jpg")
+	})
+
+	t.Run("StopBeforeTheQuotaIsExceeded", func(t *testing.T) {
+		uploaded = nil
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "c.jpg"), []byte("cc"), 0644))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "d.jpg"), []byte("d"), 0644))
+		quota := func() (*Quota, error) {
+			return &Quota{Used: 98, Limit: 100, CountsAgainstStorage: true}, nil
+		}
+
+		// broken.jpg fails so it takes nothing from the 2 bytes left, c.jpg takes them all
+		result, err := syncDir(dir, &SyncOptions{CheckQuota: true}, upload, quota)
+		assert.Equal(t, ErrorQuotaExceeded, err)
+		assert.Len(t, uploaded, 2)
+		assert.Contains(t, result.Failed, "broken.jpg")
+		assert.Contains(t, result.Uploaded, "c.jpg")
+		assert.NotContains(t, result.Uploaded, "d.jpg")
+	})
+}
+
+func TestSyncDirResume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gphoto-sync")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a.jpg"), []byte("aa"), 0644))
+
+	var resumed []string
+	failing := true
+	upload := func(path string, photoID string) (*Photo, error) {
+		resumed = append(resumed, photoID)
+		photo := &Photo{ID: "id-" + filepath.Base(path)}
+		if failing {
+			return photo, &UploadError{Stage: StageMoveToAlbum, Err: errors.New("boom"), Photo: photo}
+		}
+		photo.AlbumID = "album"
+		return photo, nil
+	}
+
+	t.Run("RecordTheCommittedPhoto", func(t *testing.T) {
+		result, err := syncDir(dir, nil, upload, nil)
+		require.NoError(t, err)
+		assert.Contains(t, result.Failed, "a.jpg")
+		assert.Equal(t, []string{""}, resumed)
+
+		state, err := LoadSyncState(filepath.Join(dir, DefaultSyncStateFile))
+		require.NoError(t, err)
+		require.Contains(t, state.Files, "a.jpg")
+		assert.Equal(t, "id-a.jpg", state.Files["a.jpg"].PhotoID)
+		assert.True(t, state.Files["a.jpg"].Incomplete)
+	})
+
+	t.Run("RunTheStagesLeftOnly", func(t *testing.T) {
+		resumed = nil
+		failing = false
+
+		// The committed file counts in the storage already, it fits even though the storage is full
+		quota := func() (*Quota, error) {
+			return &Quota{Used: 100, Limit: 100, CountsAgainstStorage: true}, nil
+		}
+		result, err := syncDir(dir, &SyncOptions{CheckQuota: true}, upload, quota)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"id-a.jpg"}, resumed)
+		assert.Equal(t, "album", result.Uploaded["a.jpg"].AlbumID)
+
+		state, err := LoadSyncState(filepath.Join(dir, DefaultSyncStateFile))
+		require.NoError(t, err)
+		assert.False(t, state.Files["a.jpg"].Incomplete)
+	})
+
+	t.Run("UploadAgainOnceChanged", func(t *testing.T) {
+		resumed = nil
+		failing = true
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "b.jpg"), []byte("b"), 0644))
+		_, err := syncDir(dir, nil, upload, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{""}, resumed)
+
+		// b.jpg is committed but changes before the next sync, so the bytes must be sent again
+		resumed = nil
+		later := time.Now().Add(time.Hour)
+		require.NoError(t, os.Chtimes(filepath.Join(dir, "b.jpg"), later, later))
+		_, err = syncDir(dir, nil, upload, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{""}, resumed)
+	})
+}
@@ -0,0 +1,56 @@
+package gphoto
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	log "github.com/canhlinh/log4go"
+)
+
+const (
+	// downloadPhotoSuffix appended to the base url to get the original bytes of a photo
+	downloadPhotoSuffix = "=d"
+	// downloadVideoSuffix appended to the base url to get the original bytes of a video
+	downloadVideoSuffix = "=dv"
+)
+
+// Download writes the original bytes of a photo or a video to w.
+// The base url is resolved first, so it works with IDs whose stored url expired.
+// It returns the number of bytes written.
+func (c *Client) Download(ctx context.Context, photoID string, w io.Writer, progressHandler ProgressHandler) (int64, error) {
+	log.Info("Request to download photo %s", photoID)
+
+	photo, err := c.getPhotoInfo(photoID)
+	if err != nil {
+		return 0, err
+	}
+
+	downloadURL := photo.URL + downloadPhotoSuffix
+	if photo.MediaType == MediaTypeVideo {
+		downloadURL = photo.URL + downloadVideoSuffix
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, downloadURL, nil)
+	req = req.WithContext(ctx)
+	req.Header.Add("user-agent", ChromeUserAgent)
+	req.Header.Add("referer", "https://photos.google.com/")
+
+	res, err := c.hClient.Do(req)
+	if err != nil {
+		c.observeError(err)
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode > 299 {
+		err := &StatusError{StatusCode: res.StatusCode, Status: res.Status}
+		c.observeError(err)
+		return 0, err
+	}
+
+	result := <-copyBuffer(w, res.Body, res.ContentLength, progressHandler)
+	c.metrics.Add(MetricBytesDownloaded, result.Written)
+	c.observeError(result.Err)
+	return result.Written, result.Err
+}
@@ -0,0 +1,64 @@
+package gphoto
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownload(t *testing.T) {
+	var downloaded []string
+	status := http.StatusOK
+	client := newFakeClient(func(req *http.Request) (int, string) {
+		if req.Method == http.MethodGet {
+			downloaded = append(downloaded, req.URL.String())
+			return status, "hello"
+		}
+
+		req.ParseForm()
+		rpcID := req.URL.Query().Get("rpcids")
+		if strings.Contains(req.PostForm.Get("f.req"), "AF1QipVideo") {
+			return http.StatusOK, rpcBody(rpcID, `[["AF1QipVideo",["https://lh3.googleusercontent.com/video",1920,1080],1629549400000,"dedup2",0,1629552023000,null,{"76647426":[[null,null,1]]}],null,[]]`)
+		}
+		return http.StatusOK, rpcBody(rpcID, `[["AF1QipPhoto",["https://lh3.googleusercontent.com/photo",4032,3024],1629549478000,"dedup1",0],null,[]]`)
+	})
+
+	t.Run("Photo", func(t *testing.T) {
+		downloaded = nil
+		var progress []int64
+		var buf bytes.Buffer
+		written, err := client.Download(context.Background(), "AF1QipPhoto", &buf, func(current int64, total int64) {
+			progress = append(progress, current)
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), written)
+		assert.Equal(t, "hello", buf.String())
+		assert.Equal(t, []int64{5}, progress)
+		assert.Equal(t, []string{"https://lh3.googleusercontent.com/photo=d"}, downloaded)
+	})
+
+	t.Run("Video", func(t *testing.T) {
+		downloaded = nil
+		var buf bytes.Buffer
+		_, err := client.Download(context.Background(), "AF1QipVideo", &buf, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://lh3.googleusercontent.com/video=dv"}, downloaded)
+	})
+
+	t.Run("StatusError", func(t *testing.T) {
+		status = http.StatusNotFound
+		var buf bytes.Buffer
+		written, err := client.Download(context.Background(), "AF1QipPhoto", &buf, nil)
+		var statusErr *StatusError
+		require.True(t, errors.As(err, &statusErr))
+		assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+		assert.Equal(t, int64(0), written)
+		assert.Empty(t, buf.String())
+	})
+}
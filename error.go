@@ -1,6 +1,9 @@
 package gphoto
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	//ErrorUnknow For unexpected error
@@ -8,4 +11,42 @@ var (
 
 	// ErrorAlbumNotCreatedYet In case no album was created just return it
 	ErrorAlbumNotCreatedYet = errors.New("There is no album was created")
+
+	// ErrorIteratorDone returned by an iterator after the last item
+	ErrorIteratorDone = errors.New("No more items in iterator")
+
+	// ErrorQuotaExceeded returned when the storage of the account can't hold a file
+	ErrorQuotaExceeded = errors.New("Storage quota exceeded")
+
+	// ErrorInvalidShareURL returned when a link doesn't point to a shared album
+	ErrorInvalidShareURL = errors.New("Invalid share url")
+
+	// ErrorDuplicate returned when the library already has the uploaded file
+	ErrorDuplicate = errors.New("The file is already in the library")
 )
+
+// StatusError is returned when google photo responds with an unexpected http status
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return e.Status
+}
+
+// TrashError is returned when media items couldn't be moved to the trash.
+// IDs are the items left out of the trash, MoveToTrash can be called again with them.
+type TrashError struct {
+	IDs []string
+	Err error
+}
+
+func (e *TrashError) Error() string {
+	return fmt.Sprintf("%d media items weren't moved to the trash: %s", len(e.IDs), e.Err.Error())
+}
+
+// Unwrap returns the error of the failed request
+func (e *TrashError) Unwrap() error {
+	return e.Err
+}
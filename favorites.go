@@ -0,0 +1,90 @@
+package gphoto
+
+import (
+	log "github.com/canhlinh/log4go"
+)
+
+// The values the favorite and archive rpcs take to set or clear a flag
+const (
+	flagSet   = 1
+	flagClear = 2
+)
+
+// SetFavorite marks or unmarks media items as favorite, by chunks of AlbumBatchSize
+func (c *Client) SetFavorite(ids []string, favorite bool) error {
+	log.Info("Request to set favorite %v on %d photos", favorite, len(ids))
+
+	for _, chunk := range chunkIDs(ids, AlbumBatchSize) {
+		if _, err := c.doRPC(QueryStringSetFavorite, favoriteArgs(chunk, favorite)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetArchived moves media items to the archive, or back to the library, by chunks of AlbumBatchSize
+func (c *Client) SetArchived(ids []string, archived bool) error {
+	log.Info("Request to set archived %v on %d photos", archived, len(ids))
+
+	for _, chunk := range chunkIDs(ids, AlbumBatchSize) {
+		if _, err := c.doRPC(QueryStringSetArchived, archivedArgs(chunk, archived)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// favoriteArgs encodes the favorite flag of the items like [[[id],...],[flag]]
+func favoriteArgs(ids []string, favorite bool) []interface{} {
+	var items []interface{}
+	for _, id := range ids {
+		items = append(items, []interface{}{id})
+	}
+	return []interface{}{items, []interface{}{flagValue(favorite)}}
+}
+
+// archivedArgs encodes the archived flag of the items like [[[null,[flag],[null,null,id]],...],null,1]
+func archivedArgs(ids []string, archived bool) []interface{} {
+	var items []interface{}
+	for _, id := range ids {
+		items = append(items, []interface{}{nil, []interface{}{flagValue(archived)}, []interface{}{nil, nil, id}})
+	}
+	return []interface{}{items, nil, 1}
+}
+
+// ListFavorites gets a page of the favorite media items.
+// Use an empty pageToken for the first page.
+func (c *Client) ListFavorites(pageToken string) (*PhotoPage, error) {
+	log.Info("Request to list the favorites")
+	return c.search("", searchCategoryFavorites, pageToken)
+}
+
+// Favorites returns an iterator over the favorite media items
+func (c *Client) Favorites() *PhotoIterator {
+	return NewPhotoIterator(c.ListFavorites)
+}
+
+// ListArchive gets a page of the archived media items.
+// Use an empty pageToken for the first page.
+func (c *Client) ListArchive(pageToken string) (*PhotoPage, error) {
+	log.Info("Request to list the archive")
+
+	s, err := c.doRPC(QueryStringListArchive, []interface{}{nullString(pageToken)})
+	if err != nil {
+		return nil, err
+	}
+
+	return NewMediaItemsResponse(s).Page()
+}
+
+// ArchivedItems returns an iterator over the archived media items
+func (c *Client) ArchivedItems() *PhotoIterator {
+	return NewPhotoIterator(c.ListArchive)
+}
+
+func flagValue(set bool) int {
+	if set {
+		return flagSet
+	}
+	return flagClear
+}
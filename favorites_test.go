@@ -0,0 +1,70 @@
+package gphoto
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFavoriteArgs(t *testing.T) {
+	t.Run("Favorite", func(t *testing.T) {
+		query := NewRPCQuery(QueryStringSetFavorite, favoriteArgs([]string{"a", "b"}, true))
+		assert.Equal(t, `[[["Ftfh0","[[[\"a\"],[\"b\"]],[1]]",null,"generic"]]]`, query)
+	})
+
+	t.Run("NotFavorite", func(t *testing.T) {
+		query := NewRPCQuery(QueryStringSetFavorite, favoriteArgs([]string{"a"}, false))
+		assert.Equal(t, `[[["Ftfh0","[[[\"a\"]],[2]]",null,"generic"]]]`, query)
+	})
+}
+
+func TestArchivedArgs(t *testing.T) {
+	t.Run("Archived", func(t *testing.T) {
+		query := NewRPCQuery(QueryStringSetArchived, archivedArgs([]string{"a", "b"}, true))
+		assert.Equal(t, `[[["w7TP3c","[[[null,[1],[null,null,\"a\"]],[null,[1],[null,null,\"b\"]]],null,1]",null,"generic"]]]`, query)
+	})
+
+	t.Run("NotArchived", func(t *testing.T) {
+		query := NewRPCQuery(QueryStringSetArchived, archivedArgs([]string{"a"}, false))
+		assert.Equal(t, `[[["w7TP3c","[[[null,[2],[null,null,\"a\"]]],null,1]",null,"generic"]]]`, query)
+	})
+}
+
+func TestFavorites(t *testing.T) {
+	var queries []string
+	client := newFakeClient(func(req *http.Request) (int, string) {
+		req.ParseForm()
+		queries = append(queries, req.PostForm.Get("f.req"))
+		rpcID := req.URL.Query().Get("rpcids")
+		return http.StatusOK, rpcBody(rpcID, `[[["AF1QipPhoto",["https://lh3.googleusercontent.com/photo",4032,3024],1629549478000,"dedup1",25200000]],"NEXT_TOKEN"]`)
+	})
+
+	t.Run("SetFavoriteByChunks", func(t *testing.T) {
+		queries = nil
+		require.NoError(t, client.SetFavorite(make([]string, AlbumBatchSize+1), true))
+		assert.Len(t, queries, 2)
+
+		queries = nil
+		require.NoError(t, client.SetArchived(nil, true))
+		assert.Empty(t, queries)
+	})
+
+	t.Run("ListFavorites", func(t *testing.T) {
+		queries = nil
+		page, err := client.ListFavorites("TOKEN")
+		require.NoError(t, err)
+		assert.Equal(t, []string{`[[["EzkLib","[null,[[4]],\"TOKEN\"]",null,"generic"]]]`}, queries)
+		require.Len(t, page.Photos, 1)
+		assert.Equal(t, "NEXT_TOKEN", page.NextPageToken)
+	})
+
+	t.Run("ListArchive", func(t *testing.T) {
+		queries = nil
+		page, err := client.ListArchive("")
+		require.NoError(t, err)
+		assert.Equal(t, []string{`[[["SGgmbc","[null]",null,"generic"]]]`}, queries)
+		assert.Len(t, page.Photos, 1)
+	})
+}
@@ -0,0 +1,105 @@
+package gphoto
+
+import (
+	"time"
+
+	log "github.com/canhlinh/log4go"
+)
+
+// ListMediaItems gets a page of the library timeline, newest first.
+// Use an empty pageToken for the first page.
+func (c *Client) ListMediaItems(pageToken string) (*PhotoPage, error) {
+	log.Info("Request to list media items")
+
+	s, err := c.doRPC(QueryStringListMediaItems, []interface{}{nullString(pageToken), nil, nil, nil, 1})
+	if err != nil {
+		return nil, err
+	}
+
+	return NewMediaItemsResponse(s).Page()
+}
+
+// MediaItems returns an iterator over the whole library timeline
+func (c *Client) MediaItems() *PhotoIterator {
+	return NewPhotoIterator(c.ListMediaItems)
+}
+
+// ListAlbumItems gets a page of the media items in an album.
+// Use an empty pageToken for the first page.
+func (c *Client) ListAlbumItems(albumID string, pageToken string) (*PhotoPage, error) {
+	log.Info("Request to list the items of album %s", albumID)
+
+	s, err := c.doRPC(QueryStringListAlbumItems, []interface{}{albumID, nullString(pageToken), nil, nil})
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := NewMediaItemsResponse(s).Page()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, photo := range page.Photos {
+		photo.AlbumID = albumID
+	}
+	return page, nil
+}
+
+// AlbumItems returns an iterator over the media items in an album
+func (c *Client) AlbumItems(albumID string) *PhotoIterator {
+	return NewPhotoIterator(func(pageToken string) (*PhotoPage, error) {
+		return c.ListAlbumItems(albumID, pageToken)
+	})
+}
+
+// GetPhoto gets a media item with all its details: the file name, the description,
+// the capture time and timezone, the dimensions, the size, the camera, the location,
+// the albums it belongs to, and a fresh base url.
+func (c *Client) GetPhoto(photoID string) (*Photo, error) {
+	photo, err := c.getPhotoInfo(photoID)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info("Request to get the details of photo %s", photoID)
+	s, err := c.doRPC(QueryStringGetMediaItemDetails, []interface{}{photoID})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := NewMediaItemDetailsResponse(s).Apply(photo); err != nil {
+		return nil, err
+	}
+	return photo, nil
+}
+
+// SetDescription changes the description of a media item. An empty text removes it.
+func (c *Client) SetDescription(photoID, text string) error {
+	log.Info("Request to set the description of photo %s", photoID)
+
+	_, err := c.doRPC(QueryStringSetDescription, []interface{}{nil, text, photoID})
+	return err
+}
+
+// SetCaptureTime changes the capture time of a media item.
+// The time is shown in the timezone tz, or in the location of t when tz is nil.
+func (c *Client) SetCaptureTime(photoID string, t time.Time, tz *time.Location) error {
+	log.Info("Request to set the capture time of photo %s to %s", photoID, t)
+
+	_, err := c.doRPC(QueryStringSetCaptureTime, captureTimeArgs(photoID, t, tz))
+	return err
+}
+
+// captureTimeArgs encodes the capture time like [[[photoID,timestamp,timezoneOffset]]], both in milliseconds
+func captureTimeArgs(photoID string, t time.Time, tz *time.Location) []interface{} {
+	if tz != nil {
+		t = t.In(tz)
+	}
+	_, offset := t.Zone()
+
+	return []interface{}{
+		[]interface{}{
+			[]interface{}{photoID, t.UnixNano() / int64(time.Millisecond), offset * 1000},
+		},
+	}
+}
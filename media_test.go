@@ -0,0 +1,52 @@
+package gphoto
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaptureTimeArgs(t *testing.T) {
+	taken := time.Date(1978, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	t.Run("TimezoneOfTheTime", func(t *testing.T) {
+		query := NewRPCQuery(QueryStringSetCaptureTime, captureTimeArgs("AF1Qip", taken, nil))
+		assert.Equal(t, `[[["DaSgWe","[[[\"AF1Qip\",252583200000,0]]]",null,"generic"]]]`, query)
+	})
+
+	t.Run("GivenTimezone", func(t *testing.T) {
+		query := NewRPCQuery(QueryStringSetCaptureTime, captureTimeArgs("AF1Qip", taken, time.FixedZone("", 7*3600)))
+		assert.Equal(t, `[[["DaSgWe","[[[\"AF1Qip\",252583200000,25200000]]]",null,"generic"]]]`, query)
+	})
+}
+
+func TestListAlbumItems(t *testing.T) {
+	var queries []string
+	client := newFakeClient(func(req *http.Request) (int, string) {
+		req.ParseForm()
+		queries = append(queries, req.PostForm.Get("f.req"))
+		rpcID := req.URL.Query().Get("rpcids")
+		return http.StatusOK, rpcBody(rpcID, `[[["AF1QipPhoto",["https://lh3.googleusercontent.com/photo",4032,3024],1629549478000,"dedup1",25200000]],"NEXT_TOKEN"]`)
+	})
+
+	t.Run("FirstPage", func(t *testing.T) {
+		queries = nil
+		page, err := client.ListAlbumItems("AF1QipAlbum", "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{`[[["snAcKc","[\"AF1QipAlbum\",null,null,null]",null,"generic"]]]`}, queries)
+		require.Len(t, page.Photos, 1)
+		assert.Equal(t, "AF1QipPhoto", page.Photos[0].ID)
+		assert.Equal(t, "AF1QipAlbum", page.Photos[0].AlbumID)
+		assert.Equal(t, "NEXT_TOKEN", page.NextPageToken)
+	})
+
+	t.Run("NextPage", func(t *testing.T) {
+		queries = nil
+		_, err := client.ListAlbumItems("AF1QipAlbum", "TOKEN")
+		require.NoError(t, err)
+		assert.Equal(t, []string{`[[["snAcKc","[\"AF1QipAlbum\",\"TOKEN\",null,null]",null,"generic"]]]`}, queries)
+	})
+}
@@ -0,0 +1,234 @@
+package gphoto
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"regexp"
+	"sync"
+	"time"
+)
+
+const (
+	// MetricBytesUploaded counts the bytes sent to the upload endpoint
+	MetricBytesUploaded = "bytes_uploaded"
+
+	// MetricBytesDownloaded counts the bytes received by Download
+	MetricBytesDownloaded = "bytes_downloaded"
+
+	// MetricUploadSize is a histogram of the size of every uploaded file
+	MetricUploadSize = "upload_size_bytes"
+
+	// MetricStageLatency is the prefix of the per-stage latency histograms, in milliseconds
+	MetricStageLatency = "stage_latency_ms."
+
+	// MetricRPCCalls is the prefix of the per-rpcid call counters
+	MetricRPCCalls = "rpc_calls."
+
+	// MetricRetries is the prefix of the per-stage retry counters
+	MetricRetries = "retries."
+
+	// MetricErrors is the prefix of the per-type error counters
+	MetricErrors = "errors."
+)
+
+// UploadStage names a step of the upload pipeline
+type UploadStage string
+
+const (
+	StageCreateUploadURL    UploadStage = "createUploadURL"
+	StageUpload             UploadStage = "upload"
+	StageEnableUploadedFile UploadStage = "enableUploadedFile"
+	StageMoveToAlbum        UploadStage = "moveToAlbum"
+	StageSetMetadata        UploadStage = "setMetadata"
+)
+
+var rpcIDRegex = regexp.MustCompile(`^\[\[\["([a-zA-Z0-9]+)"`)
+
+// Metrics receives the counters and histograms produced by the client.
+// Implementations must be safe for concurrent use.
+type Metrics interface {
+	// Add increments the counter name by delta
+	Add(name string, delta int64)
+	// Observe records one sample of the histogram name
+	Observe(name string, value float64)
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) Add(string, int64)       {}
+func (noopMetrics) Observe(string, float64) {}
+
+var (
+	defaultMetrics     *ExpvarMetrics
+	defaultMetricsOnce sync.Once
+)
+
+// DefaultMetrics returns the metrics published under the expvar name "gphoto".
+// It's used by every client unless SetMetrics is called.
+func DefaultMetrics() *ExpvarMetrics {
+	defaultMetricsOnce.Do(func() {
+		defaultMetrics = NewExpvarMetrics("gphoto")
+	})
+	return defaultMetrics
+}
+
+// ExpvarMetrics is a Metrics backed by an expvar.Map
+type ExpvarMetrics struct {
+	vars       *expvar.Map
+	mu         sync.Mutex
+	histograms map[string]*histogram
+}
+
+// NewExpvarMetrics publishes a new expvar.Map under name.
+// Like expvar.Publish, it panics if the name is already in use.
+func NewExpvarMetrics(name string) *ExpvarMetrics {
+	return newExpvarMetrics(expvar.NewMap(name))
+}
+
+// newExpvarMetrics returns metrics backed by vars, which may be left unpublished
+func newExpvarMetrics(vars *expvar.Map) *ExpvarMetrics {
+	return &ExpvarMetrics{
+		vars:       vars,
+		histograms: map[string]*histogram{},
+	}
+}
+
+// Add increments the counter name by delta
+func (m *ExpvarMetrics) Add(name string, delta int64) {
+	m.vars.Add(name, delta)
+}
+
+// Observe records one sample of the histogram name
+func (m *ExpvarMetrics) Observe(name string, value float64) {
+	m.mu.Lock()
+	h, ok := m.histograms[name]
+	if !ok {
+		h = newHistogram()
+		m.histograms[name] = h
+		m.vars.Set(name, h)
+	}
+	m.mu.Unlock()
+
+	h.observe(value)
+}
+
+// histogramBuckets are the upper bounds of the histogram buckets.
+// They grow by a factor of 4 so the same layout fits milliseconds and bytes.
+var histogramBuckets = func() []float64 {
+	var buckets []float64
+	for i := 0; i < 16; i++ {
+		buckets = append(buckets, math.Pow(4, float64(i)))
+	}
+	return buckets
+}()
+
+type histogram struct {
+	mu     sync.Mutex
+	count  int64
+	sum    float64
+	min    float64
+	max    float64
+	counts []int64
+}
+
+func newHistogram() *histogram {
+	return &histogram{counts: make([]int64, len(histogramBuckets)+1)}
+}
+
+func (h *histogram) observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.count == 0 || v < h.min {
+		h.min = v
+	}
+	if h.count == 0 || v > h.max {
+		h.max = v
+	}
+	h.count++
+	h.sum += v
+
+	for i, bound := range histogramBuckets {
+		if v <= bound {
+			h.counts[i]++
+			return
+		}
+	}
+	h.counts[len(histogramBuckets)]++
+}
+
+// String implements expvar.Var
+func (h *histogram) String() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	buckets := map[string]int64{}
+	for i, bound := range histogramBuckets {
+		buckets[fmt.Sprintf("le_%.0f", bound)] = h.counts[i]
+	}
+	buckets["le_inf"] = h.counts[len(histogramBuckets)]
+
+	d, _ := json.Marshal(map[string]interface{}{
+		"count":   h.count,
+		"sum":     h.sum,
+		"min":     h.min,
+		"max":     h.max,
+		"buckets": buckets,
+	})
+	return string(d)
+}
+
+// SetMetrics specific the metrics the upload client reports to.
+func (c *Client) SetMetrics(m Metrics) *Client {
+	if m == nil {
+		m = noopMetrics{}
+	}
+	c.metrics = m
+	return c
+}
+
+// observeStage records the latency of an upload stage.
+// Its error isn't counted here, it is where the request is sent: DoQuery for the rpcs, the stage for the upload endpoints.
+func (c *Client) observeStage(stage UploadStage, start time.Time) {
+	c.metrics.Observe(MetricStageLatency+string(stage), float64(time.Since(start))/float64(time.Millisecond))
+}
+
+// observeError counts err by its type
+func (c *Client) observeError(err error) {
+	if err != nil {
+		c.metrics.Add(MetricErrors+errorType(err), 1)
+	}
+}
+
+// errorType classifies an error for the MetricErrors counters
+func errorType(err error) string {
+	switch e := err.(type) {
+	case *StatusError:
+		return "http_status"
+	case *url.Error:
+		if e.Timeout() {
+			return "timeout"
+		}
+		return "network"
+	case net.Error:
+		if e.Timeout() {
+			return "timeout"
+		}
+		return "network"
+	case *json.SyntaxError, *json.UnmarshalTypeError:
+		return "decode"
+	}
+	return "other"
+}
+
+// queryRPCID returns the rpcid of a batchexecute query, or "mutate" for the legacy mutate queries
+func queryRPCID(query string) string {
+	if m := rpcIDRegex.FindStringSubmatch(query); len(m) == 2 {
+		return m[1]
+	}
+	return "mutate"
+}
@@ -0,0 +1,68 @@
+package gphoto
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpvarMetrics(t *testing.T) {
+	m := newExpvarMetrics(new(expvar.Map).Init())
+
+	t.Run("Counters", func(t *testing.T) {
+		m.Add(MetricRPCCalls+"Z5xsfc", 1)
+		m.Add(MetricRPCCalls+"Z5xsfc", 2)
+		assert.Equal(t, "3", m.vars.Get(MetricRPCCalls+"Z5xsfc").String())
+	})
+
+	t.Run("Histograms", func(t *testing.T) {
+		name := MetricStageLatency + string(StageUpload)
+		m.Observe(name, 3)
+		m.Observe(name, 100)
+
+		var h struct {
+			Count   int64            `json:"count"`
+			Sum     float64          `json:"sum"`
+			Min     float64          `json:"min"`
+			Max     float64          `json:"max"`
+			Buckets map[string]int64 `json:"buckets"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(m.vars.Get(name).String()), &h))
+		assert.Equal(t, int64(2), h.Count)
+		assert.Equal(t, float64(103), h.Sum)
+		assert.Equal(t, float64(3), h.Min)
+		assert.Equal(t, float64(100), h.Max)
+		assert.Equal(t, int64(1), h.Buckets["le_4"])
+		assert.Equal(t, int64(1), h.Buckets["le_256"])
+	})
+}
+
+func TestQueryRPCID(t *testing.T) {
+	assert.Equal(t, "Z5xsfc", queryRPCID(`[[["Z5xsfc","[null,null,null,null,1]",null,"3"]]]`))
+	assert.Equal(t, "mutate", queryRPCID(NewMutateQuery(QueryNumberRemovePhotoFromAlbum, nil)))
+}
+
+func TestErrorType(t *testing.T) {
+	assert.Equal(t, "http_status", errorType(&StatusError{StatusCode: 500, Status: "500 Internal Server Error"}))
+	assert.Equal(t, "decode", errorType(json.Unmarshal([]byte("{"), &struct{}{})))
+	assert.Equal(t, "other", errorType(errors.New("boom")))
+}
+
+func TestStageErrorsCountedOnce(t *testing.T) {
+	m := newExpvarMetrics(new(expvar.Map).Init())
+	client := newFakeClient(func(req *http.Request) (int, string) {
+		return http.StatusInternalServerError, ""
+	}).SetMetrics(m)
+	client.setAlbumCache(Albums{{ID: "album", Name: "Album"}})
+
+	_, err := client.AssignAlbum(context.Background(), "photo", AlbumTarget{ID: "album"})
+	require.Error(t, err)
+	assert.Equal(t, "1", m.vars.Get(MetricErrors+"http_status").String())
+	assert.NotNil(t, m.vars.Get(MetricStageLatency+string(StageMoveToAlbum)))
+}
@@ -5,30 +5,255 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 
 	log "github.com/canhlinh/log4go"
 )
 
 const (
-	QueryNumberEnableImage           = 137530650
-	QueryNumberGetAlbum              = 72930366
-	QueryNumberCreateAlbum           = 79956622
-	QueryNumberAddPhotoToAlbum       = 79956622
-	QueryNumberAddPhotoToSharedAlbum = 99484733
-	QueryNumberRemovePhotoFromAlbum  = 85381832
-	QueryStringAddPhotoToAlbum       = "C2V01c"
+	QueryNumberEnableImage             = 137530650
+	QueryNumberGetAlbum                = 72930366
+	QueryNumberCreateAlbum             = 79956622
+	QueryNumberAddPhotoToAlbum         = 79956622
+	QueryNumberAddPhotoToSharedAlbum   = 99484733
+	QueryNumberRemovePhotoFromAlbum    = 85381832
+	QueryStringAddPhotoToAlbum         = "C2V01c" // adds photos to a shared album
+	QueryStringAddPhotosToLibraryAlbum = "E1Cajb" // adds photos to an album which isn't shared
+	QueryStringRemoveFromAlbum         = "ycV3Nd"
+	QueryStringFindByDedupKey          = "swbisb"
+	QueryStringListMediaItems          = "lcxiM"
+	QueryStringListAlbumItems          = "snAcKc"
+	QueryStringGetMediaItem            = "VrseUb"
+	QueryStringGetMediaItemDetails     = "fDcn4b"
+	QueryStringSetDescription          = "AQNOFd"
+	QueryStringSetCaptureTime          = "DaSgWe"
+	QueryStringSetFavorite             = "Ftfh0"
+	QueryStringSetArchived             = "w7TP3c"
+	QueryStringSearch                  = "EzkLib"
+	QueryStringListArchive             = "SGgmbc"
+	QueryStringGetQuota                = "EzwWhf"
+	QueryStringShareAlbum              = "SFKp8c"
+	QueryStringUnshareAlbum            = "UD2Ydc"
+	QueryStringGetShareInfo            = "mlGMpf"
+	QueryStringJoinSharedAlbum         = "ZyR9bd"
+	QueryStringListCollaborators       = "GPtLc"
+	QueryStringRemoveCollaborator      = "Q7QKbd"
+	QueryStringListActivity            = "zvPEbe"
+	QueryStringPostComment             = "ig2RLc"
+	QueryStringTrashItems              = "XwAOJf"
+	QueryStringListTrash               = "zy0IHe"
+	QueryStringRenameAlbum             = "DEqCDe"
+	QueryStringDeleteAlbum             = "nV6Qv"
+
+	// mediaItemVideoKey the key of the video metadata in a media item
+	mediaItemVideoKey = "76647426"
+)
+
+// MediaType the kind of a media item
+type MediaType string
+
+const (
+	MediaTypePhoto MediaType = "photo"
+	MediaTypeVideo MediaType = "video"
 )
 
 type Photo struct {
+	// ID the media key of the item
 	ID      string
 	AlbumID string
 	Name    string
-	URL     string
+	// URL the base url of the item on lh3.googleusercontent.com
+	URL    string
+	Width  int
+	Height int
+	// Timestamp the capture time, in the timezone the item was taken
+	Timestamp time.Time
+	MediaType MediaType
+	// DedupKey the key google photo uses to detect duplicated content
+	DedupKey string
+	// Size of the file
+	Size int64
+	// ContentType of the uploaded file. It's only known by Upload.
+	ContentType string
+
+	// The details below are only filled by GetPhoto
+	Description string
+	Location    *Location
+	Camera      *CameraInfo
+	// Albums the albums containing the item
+	Albums Albums
+}
+
+// Location where a media item was taken
+type Location struct {
+	Name      string
+	Latitude  float64
+	Longitude float64
+}
+
+// CameraInfo the EXIF summary of a media item
+type CameraInfo struct {
+	Make        string
+	Model       string
+	FocalLength float64
+	Aperture    float64
+	ISO         int
+	// ExposureTime in seconds
+	ExposureTime float64
+}
+
+// ShareOptions the settings of a shared album
+type ShareOptions struct {
+	// Collaborative lets the people with the link add their photos
+	Collaborative bool
+	// Comments lets the people with the link comment and like
+	Comments bool
+}
+
+// ShareInfo how an album is shared
+type ShareInfo struct {
+	AlbumID string
+	// URL the link to share
+	URL string
+	// Key the share key, required to add photos to the album
+	Key           string
+	Collaborative bool
+	Comments      bool
+}
+
+// Collaborator a member of a shared album
+type Collaborator struct {
+	ID        string
+	Name      string
+	AvatarURL string
+	Owner     bool
+}
+
+// ActivityType the kind of an activity in a shared album
+type ActivityType string
+
+const (
+	ActivityComment ActivityType = "comment"
+	ActivityLike    ActivityType = "like"
+)
+
+// activityTypes the activity types by their number in the responses
+var activityTypes = map[float64]ActivityType{
+	1: ActivityComment,
+	2: ActivityLike,
+}
+
+// Activity a comment or a like in a shared album
+type Activity struct {
+	ID     string
+	Type   ActivityType
+	Author *Collaborator
+	// Text is empty for likes
+	Text string
+	// PhotoID the item commented or liked, empty for the album itself
+	PhotoID   string
+	Timestamp time.Time
+}
+
+// ActivityPage a page of the activity of a shared album
+type ActivityPage struct {
+	Activities []*Activity
+	// NextPageToken is empty on the last page
+	NextPageToken string
+}
+
+// storagePolicyUnlimited the storage policy of the accounts whose uploads are free
+const storagePolicyUnlimited = 2
+
+// Quota the storage usage of the account
+type Quota struct {
+	Used int64
+	// Limit is 0 when the storage is unlimited
+	Limit int64
+	// CountsAgainstStorage is false when uploads are free, like the old "high quality" uploads
+	CountsAgainstStorage bool
+}
+
+// Remaining returns the bytes left, or -1 when uploads don't count against the storage
+func (q *Quota) Remaining() int64 {
+	if !q.CountsAgainstStorage || q.Limit == 0 {
+		return -1
+	}
+	if q.Used > q.Limit {
+		return 0
+	}
+	return q.Limit - q.Used
+}
+
+// Fits reports whether a file of size bytes can still be uploaded
+func (q *Quota) Fits(size int64) bool {
+	remaining := q.Remaining()
+	return remaining < 0 || size <= remaining
+}
+
+// PhotoPage a page of media items
+type PhotoPage struct {
+	Photos []*Photo
+	// NextPageToken is empty on the last page
+	NextPageToken string
+}
+
+// PhotoIterator walks through the media items page by page
+type PhotoIterator struct {
+	fetch func(pageToken string) (*PhotoPage, error)
+	page  *PhotoPage
+	index int
+}
+
+// NewPhotoIterator creates an iterator over the pages returned by fetch
+func NewPhotoIterator(fetch func(pageToken string) (*PhotoPage, error)) *PhotoIterator {
+	return &PhotoIterator{fetch: fetch}
+}
+
+// Next returns the next media item, or ErrorIteratorDone after the last one
+func (it *PhotoIterator) Next() (*Photo, error) {
+	for it.page == nil || it.index >= len(it.page.Photos) {
+		if it.page != nil && it.page.NextPageToken == "" {
+			return nil, ErrorIteratorDone
+		}
+
+		var token string
+		if it.page != nil {
+			token = it.page.NextPageToken
+		}
+		page, err := it.fetch(token)
+		if err != nil {
+			return nil, err
+		}
+		it.page = page
+		it.index = 0
+	}
+
+	photo := it.page.Photos[it.index]
+	it.index++
+	return photo, nil
 }
 
 type Album struct {
-	ID   string
-	Name string
+	ID        string
+	Name      string
+	ItemCount int
+	Cover     *Thumbnail
+	Shared    bool
+	// ShareKey the key of a shared album, only known for the albums joined by JoinSharedAlbum
+	ShareKey string
+	// StartTime and EndTime the date range of the items, in the timezone they were taken
+	StartTime  time.Time
+	EndTime    time.Time
+	CreatedAt  time.Time
+	ModifiedAt time.Time
+}
+
+// Thumbnail a preview image
+type Thumbnail struct {
+	URL    string
+	Width  int
+	Height int
 }
 
 type Albums []*Album
@@ -149,29 +374,15 @@ func (r EnableImageResponse) getInfoArray(s string) []interface{} {
 	return b
 }
 
-// getEnabledImageID un-safe function
-func (r EnableImageResponse) getEnabledImageID(body string) (s string, err error) {
-	defer func() {
-		if r := recover(); r != nil {
-			err = fmt.Errorf("%v", r)
-		}
-	}()
-
-	infoArr := r.getInfoArray(body)
-	return infoArr[0].(string), nil
-}
-
-// getEnabledImageURL un-safe function
-func (r EnableImageResponse) getEnabledImageURL(body string) (s string, err error) {
+// getEnabledImage un-safe function
+func (r EnableImageResponse) getEnabledImage(body string) (photo *Photo, err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			err = fmt.Errorf("%v", r)
 		}
 	}()
 
-	infoArr := r.getInfoArray(body)
-	infoURL := infoArr[1].([]interface{})
-	return infoURL[0].(string), err
+	return getMediaItem(r.getInfoArray(body)), nil
 }
 
 func NewDataQuery(queryNumber int, query interface{}) string {
@@ -231,11 +442,7 @@ func (al *AlbumlResponse) Albums() (albums []*Album, err error) {
 	mainArray := al.getMainArray()
 
 	for _, arr := range mainArray {
-		albumID, albumName := al.getAlbumInfo(arr.([]interface{}))
-		albums = append(albums, &Album{
-			ID:   albumID,
-			Name: albumName,
-		})
+		albums = append(albums, al.getAlbumInfo(arr.([]interface{})))
 	}
 	return albums, nil
 }
@@ -254,13 +461,514 @@ func (al *AlbumlResponse) getMainArray() []interface{} {
 	return b
 }
 
-func (al *AlbumlResponse) getAlbumInfo(b []interface{}) (string, string) {
+// getAlbumInfo un-safe function, decodes an album like
+// [id,[coverURL,width,height,...],...,{"72930366":[?,name,[start,end,?,?,created,[start,offset],[end,offset],?,?,modified],itemCount,shared,...]}]
+func (al *AlbumlResponse) getAlbumInfo(b []interface{}) *Album {
+	album := &Album{ID: b[0].(string)}
+
+	if cover, ok := b[1].([]interface{}); ok && len(cover) > 2 {
+		album.Cover = &Thumbnail{
+			URL:    cover[0].(string),
+			Width:  int(cover[1].(float64)),
+			Height: int(cover[2].(float64)),
+		}
+	}
 
 	for _, c := range b {
 		if reflect.ValueOf(c).Kind() == reflect.Map {
 			innerarray := c.(map[string]interface{})["72930366"].([]interface{})
-			return b[0].(string), innerarray[1].(string)
+			album.Name = innerarray[1].(string)
+
+			if timestamps, ok := innerarray[2].([]interface{}); ok && len(timestamps) > 9 {
+				album.CreatedAt = getTimestamp(timestamps[4], nil)
+				album.ModifiedAt = getTimestamp(timestamps[9], nil)
+				if start, ok := timestamps[5].([]interface{}); ok && len(start) > 1 {
+					album.StartTime = getTimestamp(start[0], start[1])
+				}
+				if end, ok := timestamps[6].([]interface{}); ok && len(end) > 1 {
+					album.EndTime = getTimestamp(end[0], end[1])
+				}
+			}
+
+			if len(innerarray) > 3 {
+				if count, ok := innerarray[3].(float64); ok {
+					album.ItemCount = int(count)
+				}
+			}
+			if len(innerarray) > 4 {
+				album.Shared = innerarray[4] != nil && innerarray[4] != false
+			}
 		}
 	}
-	return "", ""
+	return album
+}
+
+// AlbumBatchSize the maximum number of photos added to an album by a single request
+const AlbumBatchSize = 500
+
+// NewAddToAlbumQuery creates the query adding photos to an album
+func NewAddToAlbumQuery(albumID string, photoIDs []string) string {
+	return NewRPCQuery(QueryStringAddPhotosToLibraryAlbum, []interface{}{photoIDs, albumID})
+}
+
+// NewAddToSharedAlbumQuery creates the query adding photos to a shared album
+func NewAddToSharedAlbumQuery(albumID, shareKey string, photoIDs []string) string {
+	var items []interface{}
+	for _, id := range photoIDs {
+		items = append(items, []interface{}{[]interface{}{id}})
+	}
+
+	return NewRPCQuery(QueryStringAddPhotoToAlbum, []interface{}{
+		[]interface{}{albumID},
+		[]interface{}{2, nil, items, nil, nil, []interface{}{}, []interface{}{1}, nil, nil, nil, []interface{}{}},
+		shareKey,
+		[]interface{}{nil, nil, nil, nil, []interface{}{nil, []interface{}{}}},
+	})
+}
+
+// NewRPCQuery creates a batchexecute query calling rpcID with the JSON encoded args
+func NewRPCQuery(rpcID string, args interface{}) string {
+	a, _ := json.Marshal(args)
+	d, _ := json.Marshal(
+		[]interface{}{
+			[]interface{}{
+				[]interface{}{rpcID, string(a), nil, "generic"},
+			},
+		},
+	)
+	return string(d)
+}
+
+// getRPCPayload un-safe function, decodes the payload of a batchexecute response
+func getRPCPayload(s string) []interface{} {
+	var b []interface{}
+	json.Unmarshal([]byte(s), &b)
+	b = b[0].([]interface{})
+	obj := b[2].(string)
+
+	var payload []interface{}
+	json.Unmarshal([]byte(obj), &payload)
+	return payload
+}
+
+// getMediaItem un-safe function, decodes a media item.
+// An item looks like [mediaKey,[url,width,height,...],timestamp,dedupKey,timezoneOffset,...]
+func getMediaItem(b []interface{}) *Photo {
+	info := b[1].([]interface{})
+	photo := &Photo{
+		ID:        b[0].(string),
+		URL:       info[0].(string),
+		MediaType: MediaTypePhoto,
+	}
+
+	if len(info) > 2 {
+		width, _ := info[1].(float64)
+		height, _ := info[2].(float64)
+		photo.Width = int(width)
+		photo.Height = int(height)
+	}
+	if len(b) > 4 {
+		photo.Timestamp = getTimestamp(b[2], b[4])
+		photo.DedupKey, _ = b[3].(string)
+	}
+
+	for _, c := range b {
+		if m, ok := c.(map[string]interface{}); ok && m[mediaItemVideoKey] != nil {
+			photo.MediaType = MediaTypeVideo
+		}
+	}
+	return photo
+}
+
+// getTimestamp converts the milliseconds since epoch and the timezone offset in milliseconds to a time
+func getTimestamp(ms interface{}, offset interface{}) time.Time {
+	t, ok := ms.(float64)
+	if !ok {
+		return time.Time{}
+	}
+
+	timestamp := time.Unix(0, int64(t)*int64(time.Millisecond))
+	if o, ok := offset.(float64); ok {
+		return timestamp.In(time.FixedZone("", int(o)/1000))
+	}
+	return timestamp
+}
+
+// MediaItemsResponse the response of a media items listing
+type MediaItemsResponse struct {
+	s string
+}
+
+func NewMediaItemsResponse(s string) *MediaItemsResponse {
+	return &MediaItemsResponse{s}
+}
+
+// Page decodes a payload like [[item,...],nextPageToken,...]
+func (r *MediaItemsResponse) Page() (page *PhotoPage, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+			log.Error(err)
+		}
+	}()
+
+	payload := getRPCPayload(r.s)
+	page = &PhotoPage{}
+
+	if items, ok := payload[0].([]interface{}); ok {
+		for _, item := range items {
+			page.Photos = append(page.Photos, getMediaItem(item.([]interface{})))
+		}
+	}
+	if len(payload) > 1 {
+		page.NextPageToken, _ = payload[1].(string)
+	}
+	return page, nil
+}
+
+// MediaItemResponse the response of a single media item lookup
+type MediaItemResponse struct {
+	s string
+}
+
+func NewMediaItemResponse(s string) *MediaItemResponse {
+	return &MediaItemResponse{s}
+}
+
+// Photo decodes a payload like [item,...]
+func (r *MediaItemResponse) Photo() (photo *Photo, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+			log.Error(err)
+		}
+	}()
+
+	payload := getRPCPayload(r.s)
+	return getMediaItem(payload[0].([]interface{})), nil
+}
+
+// MediaItemDetailsResponse the response of a media item details lookup
+type MediaItemDetailsResponse struct {
+	s string
+}
+
+func NewMediaItemDetailsResponse(s string) *MediaItemDetailsResponse {
+	return &MediaItemDetailsResponse{s}
+}
+
+// Apply decodes a payload like
+// [[mediaKey,description,filename,timestamp,timezoneOffset,size,width,height,?,[name,[latE7,lngE7]],[[albumID,name],...],[make,model,focalLength,aperture,iso,exposureTime]]]
+// then sets the details to photo.
+func (r *MediaItemDetailsResponse) Apply(photo *Photo) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+			log.Error(err)
+		}
+	}()
+
+	b := getRPCPayload(r.s)[0].([]interface{})
+	photo.ID = b[0].(string)
+	photo.Description, _ = b[1].(string)
+	photo.Name, _ = b[2].(string)
+	if timestamp := getTimestamp(b[3], b[4]); !timestamp.IsZero() {
+		photo.Timestamp = timestamp
+	}
+	if size, ok := b[5].(float64); ok {
+		photo.Size = int64(size)
+	}
+	if width, ok := b[6].(float64); ok {
+		photo.Width = int(width)
+	}
+	if height, ok := b[7].(float64); ok {
+		photo.Height = int(height)
+	}
+
+	if len(b) > 9 && b[9] != nil {
+		location := b[9].([]interface{})
+		photo.Location = &Location{}
+		photo.Location.Name, _ = location[0].(string)
+		if coordinates, ok := location[1].([]interface{}); ok && len(coordinates) > 1 {
+			photo.Location.Latitude = coordinates[0].(float64) / 1e7
+			photo.Location.Longitude = coordinates[1].(float64) / 1e7
+		}
+	}
+
+	if len(b) > 10 && b[10] != nil {
+		photo.Albums = Albums{}
+		for _, a := range b[10].([]interface{}) {
+			album := a.([]interface{})
+			photo.Albums = append(photo.Albums, &Album{ID: album[0].(string), Name: album[1].(string)})
+		}
+	}
+
+	if len(b) > 11 && b[11] != nil {
+		exif := b[11].([]interface{})
+		camera := &CameraInfo{}
+		camera.Make, _ = exif[0].(string)
+		camera.Model, _ = exif[1].(string)
+		camera.FocalLength, _ = exif[2].(float64)
+		camera.Aperture, _ = exif[3].(float64)
+		if iso, ok := exif[4].(float64); ok {
+			camera.ISO = int(iso)
+		}
+		camera.ExposureTime, _ = exif[5].(float64)
+		photo.Camera = camera
+	}
+	return nil
+}
+
+// DedupResponse the response of a lookup of media items by dedup key
+type DedupResponse struct {
+	s string
+}
+
+func NewDedupResponse(s string) *DedupResponse {
+	return &DedupResponse{s}
+}
+
+// MediaKey decodes a payload like [[[dedupKey,mediaKey],...]] and returns the media key of dedupKey.
+// It's empty when the library has no item of that content.
+func (r *DedupResponse) MediaKey(dedupKey string) (mediaKey string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+			log.Error(err)
+		}
+	}()
+
+	payload := getRPCPayload(r.s)
+	if len(payload) == 0 || payload[0] == nil {
+		return "", nil
+	}
+
+	for _, item := range payload[0].([]interface{}) {
+		pair := item.([]interface{})
+		if pair[0].(string) == dedupKey && len(pair) > 1 && pair[1] != nil {
+			return pair[1].(string), nil
+		}
+	}
+	return "", nil
+}
+
+// QuotaResponse the response of a storage quota lookup
+type QuotaResponse struct {
+	s string
+}
+
+func NewQuotaResponse(s string) *QuotaResponse {
+	return &QuotaResponse{s}
+}
+
+// Quota decodes a payload like [[usedBytes,limitBytes,...],storagePolicy,...]
+func (r *QuotaResponse) Quota() (quota *Quota, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+			log.Error(err)
+		}
+	}()
+
+	payload := getRPCPayload(r.s)
+	usage := payload[0].([]interface{})
+
+	quota = &Quota{
+		Used:                 int64(usage[0].(float64)),
+		CountsAgainstStorage: true,
+	}
+	if limit, ok := usage[1].(float64); ok {
+		quota.Limit = int64(limit)
+	}
+	if len(payload) > 1 {
+		if policy, ok := payload[1].(float64); ok {
+			quota.CountsAgainstStorage = policy != storagePolicyUnlimited
+		}
+	}
+	return quota, nil
+}
+
+// ShareInfoResponse the response of the share rpcs
+type ShareInfoResponse struct {
+	s string
+}
+
+func NewShareInfoResponse(s string) *ShareInfoResponse {
+	return &ShareInfoResponse{s}
+}
+
+// ShareInfo decodes a payload like [[url,key,collaborative,comments],...].
+// It returns nil when the album isn't shared.
+func (r *ShareInfoResponse) ShareInfo(albumID string) (info *ShareInfo, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+			log.Error(err)
+		}
+	}()
+
+	payload := getRPCPayload(r.s)
+	if len(payload) == 0 || payload[0] == nil {
+		return nil, nil
+	}
+
+	b := payload[0].([]interface{})
+	info = &ShareInfo{
+		AlbumID: albumID,
+		URL:     b[0].(string),
+		Key:     b[1].(string),
+	}
+	if len(b) > 3 {
+		info.Collaborative = b[2] == float64(flagSet)
+		info.Comments = b[3] == float64(flagSet)
+	}
+	return info, nil
+}
+
+// JoinAlbumResponse the response of joining a shared album
+type JoinAlbumResponse struct {
+	s string
+}
+
+func NewJoinAlbumResponse(s string) *JoinAlbumResponse {
+	return &JoinAlbumResponse{s}
+}
+
+// Album decodes a payload like [[albumID,name,itemCount,...],...]
+func (r *JoinAlbumResponse) Album(albumID, shareKey string) (album *Album, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+			log.Error(err)
+		}
+	}()
+
+	album = &Album{
+		ID:       albumID,
+		Shared:   true,
+		ShareKey: shareKey,
+	}
+
+	payload := getRPCPayload(r.s)
+	if len(payload) > 0 && payload[0] != nil {
+		b := payload[0].([]interface{})
+		album.Name, _ = b[1].(string)
+		if count, ok := b[2].(float64); ok {
+			album.ItemCount = int(count)
+		}
+	}
+	return album, nil
+}
+
+// CollaboratorsResponse the response of a collaborators listing
+type CollaboratorsResponse struct {
+	s string
+}
+
+func NewCollaboratorsResponse(s string) *CollaboratorsResponse {
+	return &CollaboratorsResponse{s}
+}
+
+// Collaborators decodes a payload like [[[userID,name,avatarURL,owner],...]]
+func (r *CollaboratorsResponse) Collaborators() (collaborators []*Collaborator, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+			log.Error(err)
+		}
+	}()
+
+	payload := getRPCPayload(r.s)
+	if payload[0] == nil {
+		return []*Collaborator{}, nil
+	}
+
+	for _, c := range payload[0].([]interface{}) {
+		collaborators = append(collaborators, getCollaborator(c.([]interface{})))
+	}
+	return collaborators, nil
+}
+
+// getCollaborator un-safe function, decodes a collaborator like [userID,name,avatarURL,owner]
+func getCollaborator(b []interface{}) *Collaborator {
+	collaborator := &Collaborator{ID: b[0].(string)}
+	collaborator.Name, _ = b[1].(string)
+	if len(b) > 2 {
+		collaborator.AvatarURL, _ = b[2].(string)
+	}
+	if len(b) > 3 {
+		collaborator.Owner = b[3] == true || b[3] == float64(1)
+	}
+	return collaborator
+}
+
+// ActivityResponse the response of a shared album activity listing
+type ActivityResponse struct {
+	s string
+}
+
+func NewActivityResponse(s string) *ActivityResponse {
+	return &ActivityResponse{s}
+}
+
+// Page decodes a payload like [[[activityID,type,[userID,name,avatarURL],text,photoID,timestamp],...],nextPageToken]
+func (r *ActivityResponse) Page() (page *ActivityPage, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+			log.Error(err)
+		}
+	}()
+
+	payload := getRPCPayload(r.s)
+	page = &ActivityPage{}
+
+	if items, ok := payload[0].([]interface{}); ok {
+		for _, item := range items {
+			b := item.([]interface{})
+			activity := &Activity{
+				ID:        b[0].(string),
+				Type:      activityTypes[b[1].(float64)],
+				Author:    getCollaborator(b[2].([]interface{})),
+				Timestamp: getTimestamp(b[5], nil),
+			}
+			activity.Text, _ = b[3].(string)
+			activity.PhotoID, _ = b[4].(string)
+			page.Activities = append(page.Activities, activity)
+		}
+	}
+	if len(payload) > 1 {
+		page.NextPageToken, _ = payload[1].(string)
+	}
+	return page, nil
+}
+
+// RemoveFromAlbumResponse the response of removing photos from an album
+type RemoveFromAlbumResponse struct {
+	s string
+}
+
+func NewRemoveFromAlbumResponse(s string) *RemoveFromAlbumResponse {
+	return &RemoveFromAlbumResponse{s}
+}
+
+// Removed decodes a payload like [["photoID",...]] into the IDs of the removed photos
+func (r *RemoveFromAlbumResponse) Removed() (ids []string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+			log.Error(err)
+		}
+	}()
+
+	ids = []string{}
+	payload := getRPCPayload(r.s)
+	if len(payload) == 0 || payload[0] == nil {
+		return ids, nil
+	}
+
+	for _, id := range payload[0].([]interface{}) {
+		ids = append(ids, id.(string))
+	}
+	return ids, nil
 }
@@ -1,6 +1,7 @@
 package gphoto
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -18,6 +19,21 @@ func TestAlbumlResponseAlbums(t *testing.T) {
 			assert.NotEmpty(t, album.ID)
 			assert.NotEmpty(t, album.Name)
 		}
+
+		album := albums[0]
+		assert.Equal(t, "FUAKEHREKKKKKKKKKKKKKKKKKKK", album.Name)
+		assert.Equal(t, 1, album.ItemCount)
+		assert.False(t, album.Shared)
+		require.NotNil(t, album.Cover)
+		assert.True(t, strings.HasPrefix(album.Cover.URL, "https://lh3.googleusercontent.com/"))
+		assert.Equal(t, 1280, album.Cover.Width)
+		assert.Equal(t, 720, album.Cover.Height)
+		assert.Equal(t, int64(1629551887), album.CreatedAt.Unix())
+		assert.Equal(t, int64(1629552023), album.ModifiedAt.Unix())
+		assert.Equal(t, int64(1629549478), album.StartTime.Unix())
+		assert.Equal(t, int64(1629549478), album.EndTime.Unix())
+		_, offset := album.StartTime.Zone()
+		assert.Equal(t, 7*3600, offset)
 	})
 
 	t.Run("Have 0 album", func(t *testing.T) {
@@ -28,3 +44,255 @@ func TestAlbumlResponseAlbums(t *testing.T) {
 		assert.Len(t, albums, 0)
 	})
 }
+
+func TestMediaItemsResponsePage(t *testing.T) {
+	t.Run("Have 2 items", func(t *testing.T) {
+		s := `[["wrb.fr","lcxiM","[[[\"AF1QipPhoto\",[\"https://lh3.googleusercontent.com/photo\",4032,3024,null,null,null,null,null,[7890]],1629549478000,\"dedup1\",25200000,1629552023283],[\"AF1QipVideo\",[\"https://lh3.googleusercontent.com/video\",1920,1080],1629549400000,\"dedup2\",-18000000,1629552023000,null,{\"76647426\":[[null,null,1]]}]],\"NEXT_TOKEN\",1629549400000]",null,null,null,"generic"]]`
+		page, err := NewMediaItemsResponse(s).Page()
+		require.NoError(t, err)
+		require.Len(t, page.Photos, 2)
+		assert.Equal(t, "NEXT_TOKEN", page.NextPageToken)
+
+		photo := page.Photos[0]
+		assert.Equal(t, "AF1QipPhoto", photo.ID)
+		assert.Equal(t, "https://lh3.googleusercontent.com/photo", photo.URL)
+		assert.Equal(t, 4032, photo.Width)
+		assert.Equal(t, 3024, photo.Height)
+		assert.Equal(t, MediaTypePhoto, photo.MediaType)
+		assert.Equal(t, int64(1629549478), photo.Timestamp.Unix())
+		_, offset := photo.Timestamp.Zone()
+		assert.Equal(t, 7*3600, offset)
+
+		assert.Equal(t, MediaTypeVideo, page.Photos[1].MediaType)
+	})
+
+	t.Run("Have 0 item", func(t *testing.T) {
+		s := `[["wrb.fr","lcxiM","[null,null,1629549400000]",null,null,null,"generic"]]`
+		page, err := NewMediaItemsResponse(s).Page()
+		require.NoError(t, err)
+		assert.Empty(t, page.Photos)
+		assert.Empty(t, page.NextPageToken)
+	})
+
+	t.Run("Unexpected payload", func(t *testing.T) {
+		_, err := NewMediaItemsResponse(`[["wrb.fr","lcxiM","[[[1]]]"]]`).Page()
+		assert.Error(t, err)
+	})
+}
+
+func TestPhotoIterator(t *testing.T) {
+	pages := map[string]*PhotoPage{
+		"":   {Photos: []*Photo{{ID: "1"}, {ID: "2"}}, NextPageToken: "p2"},
+		"p2": {NextPageToken: "p3"},
+		"p3": {Photos: []*Photo{{ID: "3"}}},
+	}
+	it := NewPhotoIterator(func(token string) (*PhotoPage, error) {
+		return pages[token], nil
+	})
+
+	var ids []string
+	for {
+		photo, err := it.Next()
+		if err == ErrorIteratorDone {
+			break
+		}
+		require.NoError(t, err)
+		ids = append(ids, photo.ID)
+	}
+	assert.Equal(t, []string{"1", "2", "3"}, ids)
+}
+
+func TestMediaItemResponsePhoto(t *testing.T) {
+	s := `[["wrb.fr","VrseUb","[[\"AF1QipVideo\",[\"https://lh3.googleusercontent.com/video\",1920,1080],1629549400000,\"dedup2\",-18000000,1629552023000,null,{\"76647426\":[[null,null,1]]}],null,[]]",null,null,null,"generic"]]`
+	photo, err := NewMediaItemResponse(s).Photo()
+	require.NoError(t, err)
+	assert.Equal(t, "AF1QipVideo", photo.ID)
+	assert.Equal(t, "https://lh3.googleusercontent.com/video", photo.URL)
+	assert.Equal(t, MediaTypeVideo, photo.MediaType)
+}
+
+func TestNewRPCQuery(t *testing.T) {
+	query := NewRPCQuery(QueryStringTrashItems, []interface{}{nil, 1, []string{"a", "b\"c"}, 3})
+	assert.Equal(t, `[[["XwAOJf","[null,1,[\"a\",\"b\\\"c\"],3]",null,"generic"]]]`, query)
+	assert.Equal(t, QueryStringTrashItems, queryRPCID(query))
+}
+
+func TestEnableImageResponse(t *testing.T) {
+	s := `[["wrb.fr","mdpdU","[[[\"CAIS\",[\"AF1QipUploaded\",[\"https://lh3.googleusercontent.com/uploaded\",1280,720],1629549478000,\"dedupKey\",25200000,1629552023283]]]]"]]`
+
+	var r EnableImageResponse
+	photo, err := r.getEnabledImage(s)
+	require.NoError(t, err)
+	assert.Equal(t, "AF1QipUploaded", photo.ID)
+	assert.Equal(t, "https://lh3.googleusercontent.com/uploaded", photo.URL)
+	assert.Equal(t, 1280, photo.Width)
+	assert.Equal(t, 720, photo.Height)
+	assert.Equal(t, "dedupKey", photo.DedupKey)
+	assert.Equal(t, int64(1629549478), photo.Timestamp.Unix())
+
+	_, err = r.getEnabledImage(`[["wrb.fr","mdpdU","[]"]]`)
+	assert.Error(t, err)
+}
+
+func TestMediaItemDetailsResponseApply(t *testing.T) {
+	s := `[["wrb.fr","fDcn4b","[[\"AF1QipPhoto\",\"Grandpa in 1978\",\"scan_0042.jpg\",252460800000,3600000,2345678,3000,2000,null,[\"Paris\",[488566140,23522219]],[[\"AF1QipAlbum\",\"Scans\"]],[\"Canon\",\"EOS 5D\",50,1.8,400,0.004]]]",null,null,null,"generic"]]`
+
+	photo := &Photo{URL: "https://lh3.googleusercontent.com/photo"}
+	require.NoError(t, NewMediaItemDetailsResponse(s).Apply(photo))
+	assert.Equal(t, "AF1QipPhoto", photo.ID)
+	assert.Equal(t, "https://lh3.googleusercontent.com/photo", photo.URL)
+	assert.Equal(t, "Grandpa in 1978", photo.Description)
+	assert.Equal(t, "scan_0042.jpg", photo.Name)
+	assert.Equal(t, int64(252460800), photo.Timestamp.Unix())
+	_, offset := photo.Timestamp.Zone()
+	assert.Equal(t, 3600, offset)
+	assert.Equal(t, int64(2345678), photo.Size)
+	assert.Equal(t, 3000, photo.Width)
+	assert.Equal(t, 2000, photo.Height)
+
+	require.NotNil(t, photo.Location)
+	assert.Equal(t, "Paris", photo.Location.Name)
+	assert.InDelta(t, 48.8566140, photo.Location.Latitude, 1e-7)
+	assert.InDelta(t, 2.3522219, photo.Location.Longitude, 1e-7)
+
+	require.Len(t, photo.Albums, 1)
+	assert.Equal(t, "Scans", photo.Albums[0].Name)
+
+	require.NotNil(t, photo.Camera)
+	assert.Equal(t, "Canon", photo.Camera.Make)
+	assert.Equal(t, 400, photo.Camera.ISO)
+	assert.Equal(t, 0.004, photo.Camera.ExposureTime)
+}
+
+func TestQuotaResponse(t *testing.T) {
+	t.Run("Limited", func(t *testing.T) {
+		s := `[["wrb.fr","EzwWhf","[[16106127360,16106127360000],1]",null,null,null,"generic"]]`
+		quota, err := NewQuotaResponse(s).Quota()
+		require.NoError(t, err)
+		assert.Equal(t, int64(16106127360), quota.Used)
+		assert.Equal(t, int64(16106127360000), quota.Limit)
+		assert.True(t, quota.CountsAgainstStorage)
+		assert.Equal(t, int64(16106127360000-16106127360), quota.Remaining())
+		assert.False(t, quota.Fits(quota.Remaining()+1))
+	})
+
+	t.Run("Unlimited", func(t *testing.T) {
+		s := `[["wrb.fr","EzwWhf","[[16106127360,null],2]",null,null,null,"generic"]]`
+		quota, err := NewQuotaResponse(s).Quota()
+		require.NoError(t, err)
+		assert.False(t, quota.CountsAgainstStorage)
+		assert.Equal(t, int64(-1), quota.Remaining())
+		assert.True(t, quota.Fits(1<<40))
+	})
+}
+
+func TestShareInfoResponse(t *testing.T) {
+	t.Run("Shared", func(t *testing.T) {
+		s := `[["wrb.fr","mlGMpf","[[\"https://photos.app.goo.gl/abcdef\",\"SHARE_KEY\",1,2]]",null,null,null,"generic"]]`
+		info, err := NewShareInfoResponse(s).ShareInfo("AF1QipAlbum")
+		require.NoError(t, err)
+		require.NotNil(t, info)
+		assert.Equal(t, "AF1QipAlbum", info.AlbumID)
+		assert.Equal(t, "https://photos.app.goo.gl/abcdef", info.URL)
+		assert.Equal(t, "SHARE_KEY", info.Key)
+		assert.True(t, info.Collaborative)
+		assert.False(t, info.Comments)
+	})
+
+	t.Run("Not shared", func(t *testing.T) {
+		s := `[["wrb.fr","mlGMpf","[null]",null,null,null,"generic"]]`
+		info, err := NewShareInfoResponse(s).ShareInfo("AF1QipAlbum")
+		require.NoError(t, err)
+		assert.Nil(t, info)
+	})
+}
+
+func TestJoinAlbumResponse(t *testing.T) {
+	s := `[["wrb.fr","ZyR9bd","[[\"AF1QipAlbum\",\"Wedding\",42]]",null,null,null,"generic"]]`
+	album, err := NewJoinAlbumResponse(s).Album("AF1QipAlbum", "SHARE_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, "Wedding", album.Name)
+	assert.Equal(t, 42, album.ItemCount)
+	assert.Equal(t, "SHARE_KEY", album.ShareKey)
+	assert.True(t, album.Shared)
+}
+
+func TestCollaboratorsResponse(t *testing.T) {
+	s := `[["wrb.fr","GPtLc","[[[\"1001\",\"Alice\",\"https://lh3.googleusercontent.com/a\",true],[\"1002\",\"Bob\",null,false]]]",null,null,null,"generic"]]`
+	collaborators, err := NewCollaboratorsResponse(s).Collaborators()
+	require.NoError(t, err)
+	require.Len(t, collaborators, 2)
+	assert.Equal(t, "Alice", collaborators[0].Name)
+	assert.True(t, collaborators[0].Owner)
+	assert.Equal(t, "1002", collaborators[1].ID)
+	assert.Empty(t, collaborators[1].AvatarURL)
+	assert.False(t, collaborators[1].Owner)
+}
+
+func TestActivityResponsePage(t *testing.T) {
+	s := `[["wrb.fr","zvPEbe","[[[\"c1\",1,[\"1002\",\"Bob\"],\"Great party!\",\"AF1QipPhoto\",1629549478000],[\"l1\",2,[\"1001\",\"Alice\"],null,null,1629549400000]],\"NEXT_TOKEN\"]",null,null,null,"generic"]]`
+	page, err := NewActivityResponse(s).Page()
+	require.NoError(t, err)
+	require.Len(t, page.Activities, 2)
+	assert.Equal(t, "NEXT_TOKEN", page.NextPageToken)
+
+	comment := page.Activities[0]
+	assert.Equal(t, ActivityComment, comment.Type)
+	assert.Equal(t, "Bob", comment.Author.Name)
+	assert.Equal(t, "Great party!", comment.Text)
+	assert.Equal(t, "AF1QipPhoto", comment.PhotoID)
+	assert.Equal(t, int64(1629549478), comment.Timestamp.Unix())
+
+	like := page.Activities[1]
+	assert.Equal(t, ActivityLike, like.Type)
+	assert.Empty(t, like.Text)
+	assert.Empty(t, like.PhotoID)
+}
+
+func TestNewAddToAlbumQuery(t *testing.T) {
+	t.Run("Album", func(t *testing.T) {
+		assert.Equal(t,
+			`[[["E1Cajb","[[\"photo1\",\"photo2\"],\"album\"]",null,"generic"]]]`,
+			NewAddToAlbumQuery("album", []string{"photo1", "photo2"}),
+		)
+	})
+
+	t.Run("SharedAlbum", func(t *testing.T) {
+		assert.Equal(t,
+			`[[["C2V01c","[[\"album\"],[2,null,[[[\"photo1\"]],[[\"photo2\"]]],null,null,[],[1],null,null,null,[]],\"key\",[null,null,null,null,[null,[]]]]",null,"generic"]]]`,
+			NewAddToSharedAlbumQuery("album", "key", []string{"photo1", "photo2"}),
+		)
+	})
+}
+
+func TestRemoveFromAlbumResponse(t *testing.T) {
+	t.Run("Removed", func(t *testing.T) {
+		s := `[["wrb.fr","ycV3Nd","[[\"photo1\",\"photo2\"]]",null,null,null,"generic"]]`
+		ids, err := NewRemoveFromAlbumResponse(s).Removed()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"photo1", "photo2"}, ids)
+	})
+
+	t.Run("NothingRemoved", func(t *testing.T) {
+		s := `[["wrb.fr","ycV3Nd","[]",null,null,null,"generic"]]`
+		ids, err := NewRemoveFromAlbumResponse(s).Removed()
+		require.NoError(t, err)
+		assert.Empty(t, ids)
+	})
+}
+
+func TestDedupResponse(t *testing.T) {
+	t.Run("Found", func(t *testing.T) {
+		s := `[["wrb.fr","swbisb","[[[\"KEY\",\"AF1QipPhoto\"]]]",null,null,null,"generic"]]`
+		mediaKey, err := NewDedupResponse(s).MediaKey("KEY")
+		require.NoError(t, err)
+		assert.Equal(t, "AF1QipPhoto", mediaKey)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		s := `[["wrb.fr","swbisb","[]",null,null,null,"generic"]]`
+		mediaKey, err := NewDedupResponse(s).MediaKey("KEY")
+		require.NoError(t, err)
+		assert.Empty(t, mediaKey)
+	})
+}
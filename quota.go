@@ -0,0 +1,17 @@
+package gphoto
+
+import (
+	log "github.com/canhlinh/log4go"
+)
+
+// Quota gets the storage usage of the account
+func (c *Client) Quota() (*Quota, error) {
+	log.Info("Request to get the storage quota")
+
+	s, err := c.doRPC(QueryStringGetQuota, []interface{}{})
+	if err != nil {
+		return nil, err
+	}
+
+	return NewQuotaResponse(s).Quota()
+}
@@ -0,0 +1,77 @@
+package gphoto
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	log "github.com/canhlinh/log4go"
+)
+
+// RetryPolicy tells how many times a failed upload stage is tried again, and how long to wait between the attempts.
+// Only the network errors, the timeouts and the 429 or 5xx responses are retried.
+type RetryPolicy struct {
+	// MaxAttempts the number of attempts of a stage, the first one included. Zero or one means no retry.
+	MaxAttempts int
+	// Backoff the wait before the first retry, doubled after each retry
+	Backoff time.Duration
+	// MaxBackoff caps the wait between two attempts, unlimited when zero
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy tries a stage 3 times, waiting 1s then 2s
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 3, Backoff: time.Second, MaxBackoff: 30 * time.Second}
+
+// backoff returns the wait before the retry following the given attempt, counted from 1
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return d
+}
+
+// isRetryable reports whether trying again may succeed after err
+func isRetryable(err error) bool {
+	switch errorType(err) {
+	case "timeout", "network":
+		return true
+	case "http_status":
+		code := err.(*StatusError).StatusCode
+		return code == http.StatusTooManyRequests || code >= 500
+	}
+	return false
+}
+
+// runStage runs fn as the given upload stage, and tries it again as the policy allows.
+// A nil policy runs fn once.
+func (c *Client) runStage(ctx context.Context, stage UploadStage, policy *RetryPolicy, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		err := fn()
+		if err == nil || policy == nil || attempt >= policy.MaxAttempts || !isRetryable(err) {
+			return err
+		}
+
+		wait := policy.backoff(attempt)
+		log.Warn("Stage %s failed, retrying in %s, got error %s", stage, wait, err.Error())
+		c.metrics.Add(MetricRetries+string(stage), 1)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
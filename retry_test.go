@@ -0,0 +1,80 @@
+package gphoto
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	p := &RetryPolicy{MaxAttempts: 5, Backoff: time.Second, MaxBackoff: 3 * time.Second}
+	assert.Equal(t, time.Second, p.backoff(1))
+	assert.Equal(t, 2*time.Second, p.backoff(2))
+	assert.Equal(t, 3*time.Second, p.backoff(3))
+	assert.Equal(t, 3*time.Second, p.backoff(10))
+}
+
+func TestRunStage(t *testing.T) {
+	m := newExpvarMetrics(new(expvar.Map).Init())
+	client := NewClient().SetMetrics(m)
+	policy := &RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}
+	ctx := context.Background()
+
+	t.Run("RetryServerErrors", func(t *testing.T) {
+		var attempts int
+		err := client.runStage(ctx, StageUpload, policy, func() error {
+			attempts++
+			if attempts < 3 {
+				return &StatusError{StatusCode: 503, Status: "503 Service Unavailable"}
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, attempts)
+		assert.Equal(t, "2", m.vars.Get(MetricRetries+string(StageUpload)).String())
+	})
+
+	t.Run("GiveUpAfterMaxAttempts", func(t *testing.T) {
+		var attempts int
+		err := client.runStage(ctx, StageCreateUploadURL, policy, func() error {
+			attempts++
+			return &StatusError{StatusCode: 500, Status: "500 Internal Server Error"}
+		})
+		assert.Error(t, err)
+		assert.Equal(t, 3, attempts)
+	})
+
+	t.Run("DontRetryOtherErrors", func(t *testing.T) {
+		var attempts int
+		err := client.runStage(ctx, StageEnableUploadedFile, policy, func() error {
+			attempts++
+			return &StatusError{StatusCode: 403, Status: "403 Forbidden"}
+		})
+		assert.Error(t, err)
+		assert.Equal(t, 1, attempts)
+
+		attempts = 0
+		client.runStage(ctx, StageEnableUploadedFile, policy, func() error {
+			attempts++
+			return errors.New("boom")
+		})
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("StopWhenTheContextIsDone", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		var attempts int
+		err := client.runStage(ctx, StageUpload, policy, func() error {
+			attempts++
+			return nil
+		})
+		assert.Equal(t, context.Canceled, err)
+		assert.Zero(t, attempts)
+	})
+}
@@ -0,0 +1,41 @@
+package gphoto
+
+import (
+	log "github.com/canhlinh/log4go"
+)
+
+// The search categories, they restrict a search to a kind of items
+const (
+	searchCategoryNone      = 0
+	searchCategoryFavorites = 4
+)
+
+// SearchPage gets a page of the media items matching the query.
+// The query is matched like in the search box of the web client: things, places, people, dates and file names.
+// Use an empty pageToken for the first page.
+func (c *Client) SearchPage(query string, pageToken string) (*PhotoPage, error) {
+	log.Info("Request to search %q", query)
+	return c.search(query, searchCategoryNone, pageToken)
+}
+
+// Search returns an iterator over the media items matching the query
+func (c *Client) Search(query string) *PhotoIterator {
+	return NewPhotoIterator(func(pageToken string) (*PhotoPage, error) {
+		return c.SearchPage(query, pageToken)
+	})
+}
+
+// search runs the search rpc, with a text query, a category, or both
+func (c *Client) search(query string, category int, pageToken string) (*PhotoPage, error) {
+	var categories interface{}
+	if category != searchCategoryNone {
+		categories = []interface{}{[]interface{}{category}}
+	}
+
+	s, err := c.doRPC(QueryStringSearch, []interface{}{nullString(query), categories, nullString(pageToken)})
+	if err != nil {
+		return nil, err
+	}
+
+	return NewMediaItemsResponse(s).Page()
+}
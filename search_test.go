@@ -0,0 +1,44 @@
+package gphoto
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearch(t *testing.T) {
+	var query string
+	client := newFakeClient(func(req *http.Request) (int, string) {
+		req.ParseForm()
+		query = req.PostForm.Get("f.req")
+		return http.StatusOK, rpcBody(QueryStringSearch, `[[["AF1QipPhoto",["https://lh3.googleusercontent.com/photo",4032,3024],1629549478000,"dedup1",25200000]]]`)
+	})
+
+	t.Run("FirstPage", func(t *testing.T) {
+		page, err := client.SearchPage("beach 2019", "")
+		require.NoError(t, err)
+		assert.Equal(t, `[[["EzkLib","[\"beach 2019\",null,null]",null,"generic"]]]`, query)
+		require.Len(t, page.Photos, 1)
+		assert.Equal(t, "AF1QipPhoto", page.Photos[0].ID)
+	})
+
+	t.Run("NextPage", func(t *testing.T) {
+		_, err := client.SearchPage("beach", "TOKEN")
+		require.NoError(t, err)
+		assert.Equal(t, `[[["EzkLib","[\"beach\",null,\"TOKEN\"]",null,"generic"]]]`, query)
+	})
+
+	t.Run("CategoryWithoutText", func(t *testing.T) {
+		_, err := client.search("", searchCategoryFavorites, "")
+		require.NoError(t, err)
+		assert.Equal(t, `[[["EzkLib","[null,[[4]],null]",null,"generic"]]]`, query)
+	})
+
+	t.Run("Iterator", func(t *testing.T) {
+		photo, err := client.Search("beach").Next()
+		require.NoError(t, err)
+		assert.Equal(t, "AF1QipPhoto", photo.ID)
+	})
+}
@@ -0,0 +1,184 @@
+package gphoto
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	log "github.com/canhlinh/log4go"
+)
+
+// ShareAlbum shares an album, then returns its link and its share key.
+// Sharing an already shared album updates its options.
+func (c *Client) ShareAlbum(albumID string, opts *ShareOptions) (*ShareInfo, error) {
+	log.Info("Request to share album %s", albumID)
+
+	if opts == nil {
+		opts = &ShareOptions{}
+	}
+
+	s, err := c.doRPC(QueryStringShareAlbum, []interface{}{albumID, []interface{}{flagValue(opts.Collaborative), flagValue(opts.Comments)}})
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := NewShareInfoResponse(s).ShareInfo(albumID)
+	if err != nil {
+		return nil, err
+	}
+	if info == nil {
+		return nil, ErrorUnknow
+	}
+
+	c.setAlbumShared(albumID, true)
+	return info, nil
+}
+
+// UnshareAlbum stops sharing an album. The link stops working.
+func (c *Client) UnshareAlbum(albumID string) error {
+	log.Info("Request to unshare album %s", albumID)
+
+	if _, err := c.doRPC(QueryStringUnshareAlbum, []interface{}{albumID}); err != nil {
+		return err
+	}
+
+	c.setAlbumShared(albumID, false)
+	return nil
+}
+
+// GetShareInfo gets the link and the share key of an album. It returns nil when the album isn't shared.
+func (c *Client) GetShareInfo(albumID string) (*ShareInfo, error) {
+	log.Info("Request to get the share info of album %s", albumID)
+
+	s, err := c.doRPC(QueryStringGetShareInfo, []interface{}{albumID})
+	if err != nil {
+		return nil, err
+	}
+
+	return NewShareInfoResponse(s).ShareInfo(albumID)
+}
+
+func (c *Client) setAlbumShared(albumID string, shared bool) {
+	c.updateAlbumCache(func(albums Albums) Albums {
+		for i, album := range albums {
+			if album.ID == albumID {
+				updated := *album
+				updated.Shared = shared
+				albums[i] = &updated
+			}
+		}
+		return albums
+	})
+}
+
+// ParseShareURL gets the album ID and the share key of a link like https://photos.google.com/share/<albumID>?key=<key>
+func ParseShareURL(shareURL string) (albumID string, key string, err error) {
+	u, err := url.Parse(shareURL)
+	if err != nil {
+		return "", "", err
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i, part := range parts {
+		if part == "share" && i+1 < len(parts) {
+			albumID = parts[i+1]
+		}
+	}
+	key = u.Query().Get("key")
+
+	if albumID == "" || key == "" {
+		return "", "", ErrorInvalidShareURL
+	}
+	return albumID, key, nil
+}
+
+// resolveShareURL follows the redirects of a short link like https://photos.app.goo.gl/<id>
+func (c *Client) resolveShareURL(shareURL string) (string, error) {
+	if _, _, err := ParseShareURL(shareURL); err == nil {
+		return shareURL, nil
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, shareURL, nil)
+	req.Header.Add("user-agent", ChromeUserAgent)
+
+	res, err := c.hClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode > 299 {
+		return "", &StatusError{StatusCode: res.StatusCode, Status: res.Status}
+	}
+	return res.Request.URL.String(), nil
+}
+
+// JoinSharedAlbum joins an album shared by someone else, from its link.
+// Both the short links and the photos.google.com/share links are accepted.
+// The returned album carries the share key needed by AddPhotoToSharedAlbum and UploadToSharedAlbum.
+func (c *Client) JoinSharedAlbum(shareURL string) (*Album, error) {
+	log.Info("Request to join the shared album %s", shareURL)
+
+	resolved, err := c.resolveShareURL(shareURL)
+	if err != nil {
+		return nil, err
+	}
+	albumID, key, err := ParseShareURL(resolved)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := c.doRPC(QueryStringJoinSharedAlbum, []interface{}{albumID, key})
+	if err != nil {
+		return nil, err
+	}
+
+	return NewJoinAlbumResponse(s).Album(albumID, key)
+}
+
+// UploadToSharedAlbum uploads the file then adds it to a shared album, like one returned by JoinSharedAlbum.
+func (c *Client) UploadToSharedAlbum(filePath string, filename string, album *Album, progressHandler ProgressHandler) (*Photo, error) {
+	return c.UploadFile(context.Background(), filePath, WithFilename(filename), WithSharedAlbum(album), WithProgress(progressHandler))
+}
+
+// ListCollaborators gets the members of a shared album, the owner included
+func (c *Client) ListCollaborators(albumID string) ([]*Collaborator, error) {
+	log.Info("Request to list the collaborators of album %s", albumID)
+
+	s, err := c.doRPC(QueryStringListCollaborators, []interface{}{albumID})
+	if err != nil {
+		return nil, err
+	}
+
+	return NewCollaboratorsResponse(s).Collaborators()
+}
+
+// RemoveCollaborator removes a member from a shared album. Only the owner of the album can do it.
+func (c *Client) RemoveCollaborator(albumID, userID string) error {
+	log.Info("Request to remove collaborator %s from album %s", userID, albumID)
+
+	_, err := c.doRPC(QueryStringRemoveCollaborator, []interface{}{albumID, []string{userID}})
+	return err
+}
+
+// ListActivity gets a page of the comments and likes of a shared album, newest first.
+// Use an empty pageToken for the first page.
+func (c *Client) ListActivity(albumID string, pageToken string) (*ActivityPage, error) {
+	log.Info("Request to list the activity of album %s", albumID)
+
+	s, err := c.doRPC(QueryStringListActivity, []interface{}{albumID, nullString(pageToken)})
+	if err != nil {
+		return nil, err
+	}
+
+	return NewActivityResponse(s).Page()
+}
+
+// PostComment posts a comment on a shared album
+func (c *Client) PostComment(albumID, text string) error {
+	log.Info("Request to post a comment on album %s", albumID)
+
+	_, err := c.doRPC(QueryStringPostComment, []interface{}{albumID, nil, text})
+	return err
+}
@@ -0,0 +1,29 @@
+package gphoto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseShareURL(t *testing.T) {
+	t.Run("ShareLink", func(t *testing.T) {
+		albumID, key, err := ParseShareURL("https://photos.google.com/share/AF1QipAlbum?key=SHARE_KEY")
+		require.NoError(t, err)
+		assert.Equal(t, "AF1QipAlbum", albumID)
+		assert.Equal(t, "SHARE_KEY", key)
+	})
+
+	t.Run("ShareLinkOfAnAccount", func(t *testing.T) {
+		albumID, key, err := ParseShareURL("https://photos.google.com/u/1/share/AF1QipAlbum?key=SHARE_KEY&pli=1")
+		require.NoError(t, err)
+		assert.Equal(t, "AF1QipAlbum", albumID)
+		assert.Equal(t, "SHARE_KEY", key)
+	})
+
+	t.Run("NotAShareLink", func(t *testing.T) {
+		_, _, err := ParseShareURL("https://photos.app.goo.gl/abcdef")
+		assert.Equal(t, ErrorInvalidShareURL, err)
+	})
+}
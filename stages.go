@@ -0,0 +1,137 @@
+package gphoto
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	log "github.com/canhlinh/log4go"
+)
+
+// The stages below make the upload pipeline of UploadFile. They can be called one by one,
+// to transfer the bytes now and commit later, or to retry only the step that failed.
+
+// UploadSession is an upload url, returned by CreateUploadSession
+type UploadSession struct {
+	// URL where the bytes of the file are sent
+	URL string
+	// Filename the name the media item gets once committed
+	Filename string
+	// Size of the file in bytes
+	Size int64
+}
+
+// TransferResult is the upload token of the bytes sent by Transfer
+type TransferResult struct {
+	Session *UploadSession
+	// UploadToken identifies the uploaded bytes until they're committed
+	UploadToken string
+}
+
+// AlbumTarget is the album a photo is assigned to: by ID, or by name when ID is empty.
+// A ShareKey makes it a shared album, the ID is then required.
+type AlbumTarget struct {
+	ID       string
+	Name     string
+	ShareKey string
+}
+
+// IsZero reports whether the target names no album
+func (t AlbumTarget) IsZero() bool {
+	return t.ID == "" && t.Name == "" && t.ShareKey == ""
+}
+
+// AlbumAssignment is the result of AssignAlbum
+type AlbumAssignment struct {
+	PhotoID string
+	AlbumID string
+}
+
+// CreateUploadSession creates an upload url for a file of the given name and size
+func (c *Client) CreateUploadSession(ctx context.Context, filename string, size int64) (*UploadSession, error) {
+	start := time.Now()
+	uploadURL, err := c.createUploadURL(ctx, filename, size)
+	c.observeStage(StageCreateUploadURL, start)
+	c.observeError(err)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UploadSession{URL: uploadURL, Filename: filename, Size: size}, nil
+}
+
+// Transfer sends session.Size bytes of r to the upload url of the session
+func (c *Client) Transfer(ctx context.Context, session *UploadSession, r io.Reader, progressHandler ProgressHandler) (*TransferResult, error) {
+	start := time.Now()
+	uploadToken, err := c.upload(ctx, session.URL, r, session.Size, progressHandler)
+	c.observeStage(StageUpload, start)
+	c.observeError(err)
+	if err != nil {
+		return nil, err
+	}
+	log.Debug("uploadToken: %s", uploadToken)
+	c.metrics.Add(MetricBytesUploaded, session.Size)
+	c.metrics.Observe(MetricUploadSize, float64(session.Size))
+
+	return &TransferResult{Session: session, UploadToken: uploadToken}, nil
+}
+
+// Commit turns the transferred bytes into a media item of the library.
+// modTime is the capture time google photo falls back to when the file has none.
+func (c *Client) Commit(ctx context.Context, transfer *TransferResult, modTime time.Time) (*Photo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if c.magicToken == "" {
+		if err := c.parseMagicToken(); err != nil {
+			return nil, err
+		}
+	}
+
+	start := time.Now()
+	photo, err := c.enableUploadedFile(transfer.UploadToken, transfer.Session.Filename, modTime.UnixNano()/int64(time.Millisecond))
+	c.observeStage(StageEnableUploadedFile, start)
+	if err != nil {
+		return nil, err
+	}
+	log.Debug("photoID: %s", photo.ID)
+
+	photo.Name = transfer.Session.Filename
+	photo.Size = transfer.Session.Size
+	return photo, nil
+}
+
+// AssignAlbum adds a photo to the target album. An album given by name is created if it doesn't exist.
+func (c *Client) AssignAlbum(ctx context.Context, photoID string, target AlbumTarget) (*AlbumAssignment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if target.ShareKey != "" && target.ID == "" {
+		return nil, errors.New("The shared album has no ID")
+	}
+
+	start := time.Now()
+	albumID, err := c.assignAlbum(photoID, target)
+	c.observeStage(StageMoveToAlbum, start)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AlbumAssignment{PhotoID: photoID, AlbumID: albumID}, nil
+}
+
+func (c *Client) assignAlbum(photoID string, target AlbumTarget) (string, error) {
+	switch {
+	case target.ShareKey != "":
+		return target.ID, c.AddPhotoToSharedAlbum(target.ID, target.ShareKey, photoID)
+	case target.ID != "":
+		return target.ID, c.AddPhotoToAlbum(target.ID, photoID)
+	}
+
+	photo, err := c.moveToAlbum(target.Name, photoID)
+	if err != nil {
+		return "", err
+	}
+	return photo.AlbumID, nil
+}
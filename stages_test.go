@@ -0,0 +1,75 @@
+package gphoto
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransfer(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		received = string(b)
+		if received == "fail" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"sessionStatus":{"state":"FINALIZED","additionalInfo":{"uploader_service.GoogleRupioAdditionalInfo":{"completionInfo":{"customerSpecificInfo":{"upload_token_base64":"TOKEN"}}}}}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient().SetMetrics(nil)
+
+	t.Run("Success", func(t *testing.T) {
+		session := &UploadSession{URL: server.URL, Filename: "a.jpg", Size: 5}
+		transfer, err := client.Transfer(context.Background(), session, strings.NewReader("hello"), nil)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", received)
+		assert.Equal(t, "TOKEN", transfer.UploadToken)
+		assert.Equal(t, session, transfer.Session)
+	})
+
+	t.Run("StatusError", func(t *testing.T) {
+		session := &UploadSession{URL: server.URL, Filename: "a.jpg", Size: 4}
+		_, err := client.Transfer(context.Background(), session, strings.NewReader("fail"), nil)
+		require.Error(t, err)
+		assert.True(t, isRetryable(err))
+	})
+}
+
+func TestAlbumTarget(t *testing.T) {
+	assert.True(t, AlbumTarget{}.IsZero())
+	assert.False(t, AlbumTarget{Name: "Holiday"}.IsZero())
+	assert.False(t, AlbumTarget{ID: "AF1QipAlbum"}.IsZero())
+	assert.False(t, AlbumTarget{ShareKey: "KEY"}.IsZero())
+}
+
+func TestAssignAlbum(t *testing.T) {
+	var requests int
+	client := newFakeClient(func(req *http.Request) (int, string) {
+		requests++
+		return http.StatusOK, ""
+	})
+
+	_, err := client.AssignAlbum(context.Background(), "AF1QipPhoto", AlbumTarget{ShareKey: "KEY"})
+	assert.EqualError(t, err, "The shared album has no ID")
+	assert.Zero(t, requests)
+
+	assignment, err := client.AssignAlbum(context.Background(), "AF1QipPhoto", AlbumTarget{ID: "AF1QipAlbum", ShareKey: "KEY"})
+	require.NoError(t, err)
+	assert.Equal(t, &AlbumAssignment{PhotoID: "AF1QipPhoto", AlbumID: "AF1QipAlbum"}, assignment)
+	assert.Equal(t, 1, requests)
+}
+
+func TestUploadFileToNilSharedAlbum(t *testing.T) {
+	client := NewClient()
+	_, err := client.UploadFile(context.Background(), "sample.jpg", WithSharedAlbum(nil))
+	assert.EqualError(t, err, "The album has no share key")
+}
@@ -0,0 +1,54 @@
+package gphoto
+
+import (
+	log "github.com/canhlinh/log4go"
+)
+
+// The actions of the QueryStringTrashItems rpc
+const (
+	trashActionMoveToTrash = 1
+	trashActionRestore     = 3
+)
+
+// MoveToTrash moves media items to the trash, by chunks of AlbumBatchSize.
+// When a chunk fails, the error is a *TrashError with the items of this chunk and the ones after.
+func (c *Client) MoveToTrash(ids ...string) error {
+	log.Info("Request to move %d photos to the trash", len(ids))
+
+	for i, chunk := range chunkIDs(ids, AlbumBatchSize) {
+		if _, err := c.doRPC(QueryStringTrashItems, []interface{}{nil, trashActionMoveToTrash, chunk, 3}); err != nil {
+			return &TrashError{IDs: ids[i*AlbumBatchSize:], Err: err}
+		}
+	}
+	return nil
+}
+
+// RestoreFromTrash restores media items from the trash, by chunks of AlbumBatchSize
+func (c *Client) RestoreFromTrash(ids ...string) error {
+	log.Info("Request to restore %d photos from the trash", len(ids))
+
+	for _, chunk := range chunkIDs(ids, AlbumBatchSize) {
+		if _, err := c.doRPC(QueryStringTrashItems, []interface{}{nil, trashActionRestore, chunk, 2}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListTrash gets a page of the media items in the trash.
+// Use an empty pageToken for the first page.
+func (c *Client) ListTrash(pageToken string) (*PhotoPage, error) {
+	log.Info("Request to list the trash")
+
+	s, err := c.doRPC(QueryStringListTrash, []interface{}{nullString(pageToken)})
+	if err != nil {
+		return nil, err
+	}
+
+	return NewMediaItemsResponse(s).Page()
+}
+
+// TrashItems returns an iterator over the media items in the trash
+func (c *Client) TrashItems() *PhotoIterator {
+	return NewPhotoIterator(c.ListTrash)
+}
@@ -0,0 +1,64 @@
+package gphoto
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrash(t *testing.T) {
+	var queries []string
+	failAfter := -1
+	client := newFakeClient(func(req *http.Request) (int, string) {
+		req.ParseForm()
+		queries = append(queries, req.PostForm.Get("f.req"))
+		if failAfter >= 0 && len(queries) > failAfter {
+			return http.StatusInternalServerError, ""
+		}
+		return http.StatusOK, rpcBody(QueryStringTrashItems, "[]")
+	})
+
+	t.Run("MoveToTrashByChunks", func(t *testing.T) {
+		queries = nil
+		ids := make([]string, AlbumBatchSize+1)
+		for i := range ids {
+			ids[i] = "a"
+		}
+		require.NoError(t, client.MoveToTrash(ids...))
+		require.Len(t, queries, 2)
+		assert.Equal(t, `[[["XwAOJf","[null,1,[\"a\"],3]",null,"generic"]]]`, queries[1])
+	})
+
+	t.Run("ReturnTheItemsLeftOut", func(t *testing.T) {
+		queries = nil
+		failAfter = 1
+		defer func() { failAfter = -1 }()
+
+		ids := make([]string, AlbumBatchSize+1)
+		for i := range ids {
+			ids[i] = fmt.Sprintf("photo%d", i)
+		}
+		err := client.MoveToTrash(ids...)
+		var trashErr *TrashError
+		require.True(t, errors.As(err, &trashErr))
+		assert.Equal(t, []string{fmt.Sprintf("photo%d", AlbumBatchSize)}, trashErr.IDs)
+		var statusErr *StatusError
+		assert.True(t, errors.As(err, &statusErr))
+	})
+
+	t.Run("RestoreFromTrash", func(t *testing.T) {
+		queries = nil
+		require.NoError(t, client.RestoreFromTrash("a", "b"))
+		assert.Equal(t, []string{`[[["XwAOJf","[null,3,[\"a\",\"b\"],2]",null,"generic"]]]`}, queries)
+	})
+
+	t.Run("NothingToSend", func(t *testing.T) {
+		queries = nil
+		require.NoError(t, client.MoveToTrash())
+		assert.Empty(t, queries)
+	})
+}
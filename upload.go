@@ -0,0 +1,329 @@
+package gphoto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	log "github.com/canhlinh/log4go"
+)
+
+// DedupPolicy tells what UploadFile does with a file the library already has
+type DedupPolicy int
+
+const (
+	// DedupNone uploads the file anyway. Google photo may still return the existing item.
+	DedupNone DedupPolicy = iota
+	// DedupSkip doesn't upload the file, the existing item gets the album and the metadata instead
+	DedupSkip
+	// DedupFail doesn't upload the file, UploadFile returns the existing item with ErrorDuplicate
+	DedupFail
+)
+
+// UploadOption changes the way UploadFile uploads a file
+type UploadOption func(*uploadOptions)
+
+type uploadOptions struct {
+	filename        string
+	album           AlbumTarget
+	shared          bool
+	captureTime     time.Time
+	description     string
+	dedup           DedupPolicy
+	progressHandler ProgressHandler
+	retry           *RetryPolicy
+}
+
+// WithFilename sets the file name shown in google photo, the base name of the file by default
+func WithFilename(filename string) UploadOption {
+	return func(o *uploadOptions) {
+		o.filename = filename
+	}
+}
+
+// WithAlbum adds the photo to the album named album, created if it doesn't exist.
+// The album options replace each other, an empty album leaves the photo in the library only.
+func WithAlbum(album string) UploadOption {
+	return func(o *uploadOptions) {
+		o.album, o.shared = AlbumTarget{Name: album}, false
+	}
+}
+
+// WithAlbumID adds the photo to the album of the given ID.
+// The album options replace each other.
+func WithAlbumID(albumID string) UploadOption {
+	return func(o *uploadOptions) {
+		o.album, o.shared = AlbumTarget{ID: albumID}, false
+	}
+}
+
+// WithSharedAlbum adds the photo to a shared album, like the one returned by JoinSharedAlbum.
+// The album options replace each other.
+func WithSharedAlbum(album *Album) UploadOption {
+	return func(o *uploadOptions) {
+		o.album, o.shared = AlbumTarget{}, true
+		if album != nil {
+			o.album = AlbumTarget{ID: album.ID, ShareKey: album.ShareKey}
+		}
+	}
+}
+
+// WithCaptureTime overrides the capture time of the photo, shown in the location of t
+func WithCaptureTime(t time.Time) UploadOption {
+	return func(o *uploadOptions) {
+		o.captureTime = t
+	}
+}
+
+// WithDescription sets the description of the photo
+func WithDescription(text string) UploadOption {
+	return func(o *uploadOptions) {
+		o.description = text
+	}
+}
+
+// WithDedup sets what to do when the library already has the file, see DedupPolicy
+func WithDedup(policy DedupPolicy) UploadOption {
+	return func(o *uploadOptions) {
+		o.dedup = policy
+	}
+}
+
+// WithProgress sets the handler notified while the bytes of the file are sent
+func WithProgress(progressHandler ProgressHandler) UploadOption {
+	return func(o *uploadOptions) {
+		o.progressHandler = progressHandler
+	}
+}
+
+// WithRetry tries the failed stages of the upload again, as the policy allows
+func WithRetry(policy RetryPolicy) UploadOption {
+	return func(o *uploadOptions) {
+		o.retry = &policy
+	}
+}
+
+// UploadError is returned by UploadFile when a stage of the upload fails.
+// Photo is the media item so far, it's nil unless the file was committed to the library.
+// Resume finishes the upload from the stage which failed.
+type UploadError struct {
+	Stage UploadStage
+	Err   error
+	Photo *Photo
+
+	state *uploadState
+}
+
+func (e *UploadError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Stage, e.Err.Error())
+}
+
+// Unwrap returns the error of the stage
+func (e *UploadError) Unwrap() error {
+	return e.Err
+}
+
+// uploadState keeps what the stages of an upload have done so far
+type uploadState struct {
+	filePath     string
+	opts         *uploadOptions
+	session      *UploadSession
+	transfer     *TransferResult
+	photo        *Photo
+	metadataDone bool
+}
+
+// fail tags err with the stage which failed
+func (st *uploadState) fail(stage UploadStage, err error) *UploadError {
+	return &UploadError{Stage: stage, Err: err, Photo: st.photo, state: st}
+}
+
+// UploadFile uploads the file to the google photo, then applies the metadata and the album of the options.
+// The upload is aborted when ctx is done.
+// When a stage fails, the error is an *UploadError and the photo is returned if it was committed already.
+func (c *Client) UploadFile(ctx context.Context, filePath string, opts ...UploadOption) (*Photo, error) {
+	o := &uploadOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if o.shared && o.album.ShareKey == "" {
+		return nil, errors.New("The album has no share key")
+	}
+	if o.shared && o.album.ID == "" {
+		return nil, errors.New("The shared album has no ID")
+	}
+
+	st := &uploadState{filePath: filePath, opts: o}
+	if o.dedup != DedupNone {
+		existing, err := c.findExisting(filePath, o.filename)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil && o.dedup == DedupFail {
+			return existing, ErrorDuplicate
+		}
+		st.photo = existing
+	}
+
+	return c.runUpload(ctx, st)
+}
+
+// Resume finishes an upload which failed with err, from the stage which failed.
+// The stages done already aren't run again, so the file isn't uploaded twice once committed.
+func (c *Client) Resume(ctx context.Context, err *UploadError) (*Photo, error) {
+	if err.state == nil {
+		return err.Photo, errors.New("The upload can't be resumed")
+	}
+	return c.runUpload(ctx, err.state)
+}
+
+// uploadToAlbum uploads the file to the album named album like Upload, until ctx is done.
+// With a photoID the file was committed already as that media item, only the stages left are run.
+// It lets SyncDir and the Watcher finish an upload after the *UploadError is gone, across runs.
+func (c *Client) uploadToAlbum(ctx context.Context, filePath string, photoID string, album string) (*Photo, error) {
+	if photoID == "" {
+		return c.UploadFile(ctx, filePath, WithAlbum(album))
+	}
+
+	log.Info("Resume the upload of file %s as %s", filePath, photoID)
+	photo := &Photo{ID: photoID}
+	if err := fillFromFile(photo, filePath, ""); err != nil {
+		return nil, err
+	}
+
+	st := &uploadState{filePath: filePath, opts: &uploadOptions{album: AlbumTarget{Name: album}}, photo: photo}
+	return c.runUpload(ctx, st)
+}
+
+// committedPhoto returns the media item of an upload which failed after the commit, or nil
+func committedPhoto(err error) *Photo {
+	var uploadErr *UploadError
+	if errors.As(err, &uploadErr) {
+		return uploadErr.Photo
+	}
+	return nil
+}
+
+// runUpload runs the stages of the upload which aren't done yet
+func (c *Client) runUpload(ctx context.Context, st *uploadState) (*Photo, error) {
+	o := st.opts
+
+	if st.photo == nil {
+		if err := c.uploadFile(ctx, st); err != nil {
+			return st.photo, err
+		}
+	}
+	photo := st.photo
+
+	if !st.metadataDone {
+		if o.description != "" {
+			err := c.runStage(ctx, StageSetMetadata, o.retry, func() error {
+				return c.setMetadata(func() error { return c.SetDescription(photo.ID, o.description) })
+			})
+			if err != nil {
+				log.Error("Failed to set the description, got error %s", err.Error())
+				return photo, st.fail(StageSetMetadata, err)
+			}
+			photo.Description = o.description
+		}
+
+		if !o.captureTime.IsZero() {
+			err := c.runStage(ctx, StageSetMetadata, o.retry, func() error {
+				return c.setMetadata(func() error { return c.SetCaptureTime(photo.ID, o.captureTime, nil) })
+			})
+			if err != nil {
+				log.Error("Failed to set the capture time, got error %s", err.Error())
+				return photo, st.fail(StageSetMetadata, err)
+			}
+			photo.Timestamp = o.captureTime
+		}
+		st.metadataDone = true
+	}
+
+	if o.album.IsZero() || photo.AlbumID != "" {
+		return photo, nil
+	}
+
+	var assignment *AlbumAssignment
+	err := c.runStage(ctx, StageMoveToAlbum, o.retry, func() (err error) {
+		assignment, err = c.AssignAlbum(ctx, photo.ID, o.album)
+		return err
+	})
+	if err != nil {
+		log.Error("Failed to move the photo to album, got error %s", err.Error())
+		return photo, st.fail(StageMoveToAlbum, err)
+	}
+
+	photo.AlbumID = assignment.AlbumID
+	return photo, nil
+}
+
+// setMetadata runs fn as the StageSetMetadata stage
+func (c *Client) setMetadata(fn func() error) error {
+	start := time.Now()
+	err := fn()
+	c.observeStage(StageSetMetadata, start)
+	return err
+}
+
+// findExisting returns the media item of the library having the content of the file, or nil.
+// Like an uploaded photo, its name, size and content type are the ones of the file.
+func (c *Client) findExisting(filePath string, filename string) (*Photo, error) {
+	dedupKey, err := FileDedupKey(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := c.findByDedupKey(dedupKey)
+	if err != nil || existing == nil {
+		return nil, err
+	}
+	log.Info("The file %s is already in the library as %s", filePath, existing.ID)
+
+	if err := fillFromFile(existing, filePath, filename); err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// fillFromFile sets the name, the size and the content type of the photo from the file.
+// The name is filename, or the base name of the file when it's empty.
+func fillFromFile(photo *Photo, filePath string, filename string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	if filename == "" {
+		filename = fileInfo.Name()
+	}
+	photo.Name = filename
+	photo.Size = fileInfo.Size()
+	photo.ContentType = DetectContentType(file)
+	return nil
+}
+
+// findByDedupKey returns the media item having the given dedup key, or nil if the library hasn't it
+func (c *Client) findByDedupKey(dedupKey string) (*Photo, error) {
+	log.Info("Request to find the photo of dedup key %s", dedupKey)
+
+	s, err := c.doRPC(QueryStringFindByDedupKey, []interface{}{[]string{dedupKey}, 1})
+	if err != nil {
+		return nil, err
+	}
+
+	mediaKey, err := NewDedupResponse(s).MediaKey(dedupKey)
+	if err != nil || mediaKey == "" {
+		return nil, err
+	}
+	return c.getPhotoInfo(mediaKey)
+}
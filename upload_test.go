@@ -0,0 +1,129 @@
+package gphoto
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResume(t *testing.T) {
+	var requests []string
+	status := http.StatusForbidden
+	client := NewClient().SetMetrics(nil).SetHTTPClient(&http.Client{
+		Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			requests = append(requests, req.Method+" "+req.URL.Path)
+			code := http.StatusOK
+			if req.Method == http.MethodPost {
+				code = status
+			}
+			return &http.Response{StatusCode: code, Status: http.StatusText(code), Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+		}),
+	})
+	client.magicToken = "token"
+	client.setAlbumCache(Albums{{ID: "AF1QipAlbum", Name: "Album"}})
+
+	// The photo is committed and described, only the album is missing
+	st := &uploadState{
+		filePath:     "sample.jpg",
+		opts:         &uploadOptions{album: AlbumTarget{ID: "AF1QipAlbum"}, description: "hello"},
+		photo:        &Photo{ID: "AF1QipPhoto"},
+		metadataDone: true,
+	}
+
+	photo, err := client.runUpload(context.Background(), st)
+	require.Error(t, err)
+	require.NotNil(t, photo)
+	assert.Equal(t, "AF1QipPhoto", photo.ID)
+	assert.Empty(t, photo.AlbumID)
+
+	var uploadErr *UploadError
+	require.True(t, errors.As(err, &uploadErr))
+	assert.Equal(t, StageMoveToAlbum, uploadErr.Stage)
+	assert.Equal(t, photo, uploadErr.Photo)
+	var statusErr *StatusError
+	assert.True(t, errors.As(err, &statusErr))
+
+	status = http.StatusOK
+	requests = nil
+	photo, err = client.Resume(context.Background(), uploadErr)
+	require.NoError(t, err)
+	assert.Equal(t, "AF1QipPhoto", photo.ID)
+	assert.Equal(t, "AF1QipAlbum", photo.AlbumID)
+	// Only the add to album query, the cached album tells it isn't shared
+	assert.Equal(t, []string{"POST /_/PhotosUi/data/batchexecute"}, requests)
+
+	_, err = client.Resume(context.Background(), &UploadError{Stage: StageUpload, Err: errors.New("boom")})
+	assert.Error(t, err)
+}
+
+func TestUploadFileDedup(t *testing.T) {
+	file, err := ioutil.TempFile("", "gphoto-dedup")
+	require.NoError(t, err)
+	defer os.Remove(file.Name())
+	file.WriteString("hello")
+	file.Close()
+
+	var rpcs []string
+	client := newFakeClient(func(req *http.Request) (int, string) {
+		rpcID := req.URL.Query().Get("rpcids")
+		rpcs = append(rpcs, rpcID)
+		switch rpcID {
+		case QueryStringFindByDedupKey:
+			return http.StatusOK, rpcBody(rpcID, `[[["qvTGHdzF6KLavt4PO0gs2a6pQ00","AF1QipPhoto"]]]`)
+		case QueryStringGetMediaItem:
+			return http.StatusOK, rpcBody(rpcID, `[["AF1QipPhoto",["https://lh3.googleusercontent.com/photo",4032,3024],1629549478000,"qvTGHdzF6KLavt4PO0gs2a6pQ00",0],null,[]]`)
+		}
+		return http.StatusNotFound, ""
+	})
+
+	t.Run("Skip", func(t *testing.T) {
+		rpcs = nil
+		photo, err := client.UploadFile(context.Background(), file.Name(), WithDedup(DedupSkip), WithFilename("hello.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{QueryStringFindByDedupKey, QueryStringGetMediaItem}, rpcs)
+		assert.Equal(t, "AF1QipPhoto", photo.ID)
+		assert.Equal(t, "hello.txt", photo.Name)
+		assert.Equal(t, int64(5), photo.Size)
+		assert.Equal(t, "text/plain; charset=utf-8", photo.ContentType)
+	})
+
+	t.Run("Fail", func(t *testing.T) {
+		photo, err := client.UploadFile(context.Background(), file.Name(), WithDedup(DedupFail))
+		assert.Equal(t, ErrorDuplicate, err)
+		require.NotNil(t, photo)
+		assert.Equal(t, filepath.Base(file.Name()), photo.Name)
+	})
+}
+
+func TestUploadToAlbumResume(t *testing.T) {
+	file, err := ioutil.TempFile("", "gphoto-resume")
+	require.NoError(t, err)
+	defer os.Remove(file.Name())
+	file.WriteString("hello")
+	file.Close()
+
+	var rpcs []string
+	client := newFakeClient(func(req *http.Request) (int, string) {
+		req.ParseForm()
+		rpcs = append(rpcs, queryRPCID(req.PostForm.Get("f.req")))
+		return http.StatusOK, ""
+	})
+	client.setAlbumCache(Albums{{ID: "AF1QipAlbum", Name: "Album"}})
+
+	// The file is committed already, only the album is assigned
+	photo, err := client.uploadToAlbum(context.Background(), file.Name(), "AF1QipPhoto", "Album")
+	require.NoError(t, err)
+	assert.Equal(t, []string{QueryStringAddPhotosToLibraryAlbum}, rpcs)
+	assert.Equal(t, "AF1QipPhoto", photo.ID)
+	assert.Equal(t, "AF1QipAlbum", photo.AlbumID)
+	assert.Equal(t, filepath.Base(file.Name()), photo.Name)
+	assert.Equal(t, int64(5), photo.Size)
+}
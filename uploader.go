@@ -1,6 +1,7 @@
 package gphoto
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"os"
@@ -27,12 +28,17 @@ func NewUploader(c *http.Client) *Uploader {
 }
 
 func (u *Uploader) Do(url string, file io.Reader, fileSize int64, progressHanlder ProgressHandler) (*http.Response, error) {
+	return u.DoContext(context.Background(), url, file, fileSize, progressHanlder)
+}
+
+// DoContext is like Do, the upload request is canceled with ctx.
+func (u *Uploader) DoContext(ctx context.Context, url string, file io.Reader, fileSize int64, progressHanlder ProgressHandler) (*http.Response, error) {
 	out, in, err := os.Pipe()
 	if err != nil {
 		return nil, err
 	}
 
-	c1 := u.do(url, out, fileSize)
+	c1 := u.do(ctx, url, out, fileSize)
 	c2 := copyBuffer(in, file, fileSize, progressHanlder)
 
 	//Todo: Might leaked go routine here. Need check again
@@ -50,7 +56,7 @@ func (u *Uploader) Do(url string, file io.Reader, fileSize int64, progressHanlde
 
 }
 
-func (u *Uploader) do(url string, file io.Reader, fileSize int64) chan *UploadResult {
+func (u *Uploader) do(ctx context.Context, url string, file io.Reader, fileSize int64) chan *UploadResult {
 	c := make(chan *UploadResult)
 
 	go func() {
@@ -65,6 +71,7 @@ func (u *Uploader) do(url string, file io.Reader, fileSize int64) chan *UploadRe
 			result.Err = err
 			return
 		}
+		req = req.WithContext(ctx)
 		req.ContentLength = fileSize
 		req.Header.Add("content-type", "application/octet-stream")
 		req.Header.Add("user-agent", ChromeUserAgent)
@@ -3,12 +3,17 @@ package gphoto
 import (
 	"bufio"
 	"bytes"
+	"crypto/sha1"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"mime"
 	"net/http"
 	"net/http/httputil"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 )
@@ -20,6 +25,58 @@ func NewJSONBody(model interface{}) io.Reader {
 	return buf
 }
 
+// chunkIDs splits ids in chunks of at most size ids
+func chunkIDs(ids []string, size int) [][]string {
+	var chunks [][]string
+	for len(ids) > size {
+		chunks = append(chunks, ids[:size])
+		ids = ids[size:]
+	}
+	if len(ids) > 0 {
+		chunks = append(chunks, ids)
+	}
+	return chunks
+}
+
+// nullString encodes an empty string as a JSON null
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// DetectContentType detects the content type of a file by its first bytes, then by its extension.
+// The file offset is moved back to the start.
+func DetectContentType(file *os.File) string {
+	buf := make([]byte, 512)
+	n, _ := io.ReadFull(file, buf)
+	file.Seek(0, io.SeekStart)
+
+	contentType := http.DetectContentType(buf[:n])
+	if contentType == "application/octet-stream" || strings.HasPrefix(contentType, "text/plain") {
+		if byExt := mime.TypeByExtension(filepath.Ext(file.Name())); byExt != "" {
+			return byExt
+		}
+	}
+	return contentType
+}
+
+// FileDedupKey computes the dedup key of a file: the url-safe base64 of the SHA1 of its content
+func FileDedupKey(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha1.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(hash.Sum(nil)), nil
+}
+
 func BodyToString(body io.Reader) string {
 	var buf bytes.Buffer
 	io.Copy(&buf, body)
@@ -81,6 +138,21 @@ func JsonBodyByScanLine(s string, start, end int) string {
 	return b
 }
 
+// WriteJSONFile writes v as JSON to path.
+// The data is written to a temporary file first, so an interrupted write never leaves a truncated file.
+func WriteJSONFile(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
 func WriteStringToFile(s string) {
 	file, err := os.Create("file.txt")
 	if err != nil {
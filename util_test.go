@@ -0,0 +1,46 @@
+package gphoto
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectContentType(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gphoto-content-type")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	png := filepath.Join(dir, "image.bin")
+	require.NoError(t, ioutil.WriteFile(png, []byte("\x89PNG\r\n\x1a\n0000"), 0644))
+	file, err := os.Open(png)
+	require.NoError(t, err)
+	defer file.Close()
+
+	assert.Equal(t, "image/png", DetectContentType(file))
+	offset, _ := file.Seek(0, io.SeekCurrent)
+	assert.Equal(t, int64(0), offset)
+}
+
+func TestChunkIDs(t *testing.T) {
+	assert.Empty(t, chunkIDs(nil, 2))
+	assert.Equal(t, [][]string{{"1", "2"}, {"3", "4"}, {"5"}}, chunkIDs([]string{"1", "2", "3", "4", "5"}, 2))
+	assert.Equal(t, [][]string{{"1", "2"}}, chunkIDs([]string{"1", "2"}, 2))
+}
+
+func TestFileDedupKey(t *testing.T) {
+	file, err := ioutil.TempFile("", "gphoto-dedup")
+	require.NoError(t, err)
+	defer os.Remove(file.Name())
+	file.WriteString("hello")
+	file.Close()
+
+	key, err := FileDedupKey(file.Name())
+	require.NoError(t, err)
+	assert.Equal(t, "qvTGHdzF6KLavt4PO0gs2a6pQ00", key)
+}
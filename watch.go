@@ -0,0 +1,401 @@
+package gphoto
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/canhlinh/log4go"
+)
+
+const (
+	// DefaultWatchQueueFile the queue file a Watcher keeps in its first directory when no other is given
+	DefaultWatchQueueFile = ".gphoto-queue.json"
+
+	// DefaultWatchPollInterval how often the watched directories are scanned
+	DefaultWatchPollInterval = 10 * time.Second
+
+	// DefaultWatchSettleTime how long a file must stop growing before it's uploaded
+	DefaultWatchSettleTime = 30 * time.Second
+
+	// DefaultWatchMaxBackoff the longest wait before a failed file is tried again
+	DefaultWatchMaxBackoff = time.Hour
+)
+
+// WatchOptions configures a Watcher
+type WatchOptions struct {
+	// Dirs the directories to watch, recursively. Hidden files are ignored.
+	Dirs []string
+	// Album the album the files are uploaded to
+	Album string
+	// DoneDir if set, uploaded files are moved there keeping their path relative to the watched directory
+	DoneDir string
+	// QueueFile where the pending and uploaded files are persisted. Default is DefaultWatchQueueFile in the first directory.
+	QueueFile string
+	// PollInterval default is DefaultWatchPollInterval
+	PollInterval time.Duration
+	// SettleTime default is DefaultWatchSettleTime
+	SettleTime time.Duration
+	// MaxBackoff caps the wait before a failed file is tried again, the wait doubles after each failure from PollInterval.
+	// Default is DefaultWatchMaxBackoff.
+	MaxBackoff time.Duration
+	// OnUpload is called after every upload attempt
+	OnUpload func(path string, photo *Photo, err error)
+}
+
+// WatchItem a file waiting to be uploaded
+type WatchItem struct {
+	Path      string `json:"path"`
+	Dir       string `json:"dir"`
+	Attempts  int    `json:"attempts"`
+	LastError string `json:"last_error,omitempty"`
+	// NextAttempt the file isn't tried again before that time
+	NextAttempt time.Time `json:"next_attempt"`
+	// PhotoID the media item the file was committed as before a later stage failed, the next attempt runs the stages left only
+	PhotoID string `json:"photo_id,omitempty"`
+}
+
+// watchQueue is the persisted state of a Watcher
+type watchQueue struct {
+	Pending  []*WatchItem          `json:"pending"`
+	Uploaded map[string]*SyncEntry `json:"uploaded"`
+}
+
+// fileSnapshot is the last seen state of a file that isn't queued yet
+type fileSnapshot struct {
+	size    int64
+	modTime time.Time
+	since   time.Time
+}
+
+// Watcher uploads the files that appear in a set of directories.
+// Directories are polled, so it works on any file system.
+type Watcher struct {
+	opts   WatchOptions
+	upload func(ctx context.Context, path string, photoID string) (*Photo, error)
+	queue  *watchQueue
+	seen   map[string]*fileSnapshot
+}
+
+// NewWatcher creates a Watcher uploading to the client. Call Run to start it.
+func (c *Client) NewWatcher(opts WatchOptions) (*Watcher, error) {
+	return newWatcher(opts, func(ctx context.Context, path string, photoID string) (*Photo, error) {
+		return c.uploadToAlbum(ctx, path, photoID, opts.Album)
+	})
+}
+
+// newWatcher creates a Watcher using upload, which resumes the upload of the committed media item photoID when it's given.
+// upload must give up once ctx is done.
+func newWatcher(opts WatchOptions, upload func(ctx context.Context, path string, photoID string) (*Photo, error)) (*Watcher, error) {
+	if len(opts.Dirs) == 0 {
+		return nil, errors.New("No directory to watch")
+	}
+
+	var dirs []string
+	for _, dir := range opts.Dirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, abs)
+	}
+	opts.Dirs = dirs
+
+	if opts.DoneDir != "" {
+		abs, err := filepath.Abs(opts.DoneDir)
+		if err != nil {
+			return nil, err
+		}
+		opts.DoneDir = abs
+	}
+	if opts.QueueFile == "" {
+		opts.QueueFile = filepath.Join(opts.Dirs[0], DefaultWatchQueueFile)
+	}
+	queueFile, err := filepath.Abs(opts.QueueFile)
+	if err != nil {
+		return nil, err
+	}
+	opts.QueueFile = queueFile
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultWatchPollInterval
+	}
+	if opts.SettleTime <= 0 {
+		opts.SettleTime = DefaultWatchSettleTime
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = DefaultWatchMaxBackoff
+	}
+
+	w := &Watcher{
+		opts:   opts,
+		upload: upload,
+		queue:  &watchQueue{Uploaded: map[string]*SyncEntry{}},
+		seen:   map[string]*fileSnapshot{},
+	}
+
+	if err := w.load(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Pending returns the files waiting to be uploaded
+func (w *Watcher) Pending() []*WatchItem {
+	return w.queue.Pending
+}
+
+// Run watches the directories until the context is done, aborting the upload in progress.
+// The unreadable files and the failed uploads are logged and skipped, only a queue file that can't be saved stops it.
+func (w *Watcher) Run(ctx context.Context) error {
+	log.Info("Start watching %s", strings.Join(w.opts.Dirs, ", "))
+
+	ticker := time.NewTicker(w.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.scan(time.Now()); err != nil {
+			return err
+		}
+		if err := w.process(ctx, time.Now()); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// load reads the persisted queue, dropping the files removed while the watcher was stopped
+func (w *Watcher) load() error {
+	data, err := ioutil.ReadFile(w.opts.QueueFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, w.queue); err != nil {
+		return err
+	}
+	if w.queue.Uploaded == nil {
+		w.queue.Uploaded = map[string]*SyncEntry{}
+	}
+
+	var pending []*WatchItem
+	for _, item := range w.queue.Pending {
+		if _, err := os.Stat(item.Path); err == nil {
+			pending = append(pending, item)
+		}
+	}
+	w.queue.Pending = pending
+	return nil
+}
+
+func (w *Watcher) save() error {
+	return WriteJSONFile(w.opts.QueueFile, w.queue)
+}
+
+// scan queues the files which didn't change for the settle time
+func (w *Watcher) scan(now time.Time) error {
+	queued := map[string]bool{}
+	for _, item := range w.queue.Pending {
+		queued[item.Path] = true
+	}
+
+	present := map[string]bool{}
+	changed := false
+
+	for _, dir := range w.opts.Dirs {
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				// The file may be moved away while walking, an unreadable one is tried again next scan
+				if !os.IsNotExist(err) {
+					log.Warn("Failed to read %s, got error %s", path, err.Error())
+				}
+				return nil
+			}
+
+			if path == w.opts.DoneDir && info.IsDir() {
+				return filepath.SkipDir
+			}
+			if strings.HasPrefix(info.Name(), ".") && path != dir {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !info.Mode().IsRegular() || queued[path] {
+				return nil
+			}
+			// The queue file may be in a watched directory under any name
+			if path == w.opts.QueueFile || path == w.opts.QueueFile+".tmp" {
+				return nil
+			}
+			if entry, ok := w.queue.Uploaded[path]; ok && entry.Size == info.Size() && entry.ModTime.Equal(info.ModTime()) {
+				return nil
+			}
+
+			present[path] = true
+			snapshot, ok := w.seen[path]
+			if !ok || snapshot.size != info.Size() || !snapshot.modTime.Equal(info.ModTime()) {
+				w.seen[path] = &fileSnapshot{size: info.Size(), modTime: info.ModTime(), since: now}
+				return nil
+			}
+
+			if now.Sub(snapshot.since) >= w.opts.SettleTime {
+				log.Info("Queue file %s", path)
+				w.queue.Pending = append(w.queue.Pending, &WatchItem{Path: path, Dir: dir})
+				delete(w.seen, path)
+				changed = true
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for path := range w.seen {
+		if !present[path] {
+			delete(w.seen, path)
+		}
+	}
+
+	if changed {
+		return w.save()
+	}
+	return nil
+}
+
+// process uploads the queued files. Failed files stay queued, they're tried again once their backoff is over.
+func (w *Watcher) process(ctx context.Context, now time.Time) error {
+	items := append([]*WatchItem{}, w.queue.Pending...)
+
+	for _, item := range items {
+		if ctx.Err() != nil {
+			return nil
+		}
+		if now.Before(item.NextAttempt) {
+			continue
+		}
+
+		info, err := os.Stat(item.Path)
+		if os.IsNotExist(err) {
+			w.remove(item)
+			if err := w.save(); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			log.Warn("Failed to read %s, got error %s", item.Path, err.Error())
+			continue
+		}
+
+		photo, err := w.upload(ctx, item.Path, item.PhotoID)
+		if w.opts.OnUpload != nil {
+			w.opts.OnUpload(item.Path, photo, err)
+		}
+
+		if err != nil {
+			if committed := committedPhoto(err); committed != nil {
+				item.PhotoID = committed.ID
+			}
+			// An upload aborted by the stop of the watcher isn't a failure, it's tried again on the next start
+			if ctx.Err() != nil {
+				return w.save()
+			}
+
+			log.Warn("Failed to upload %s, got error %s", item.Path, err.Error())
+			item.Attempts++
+			item.LastError = err.Error()
+			item.NextAttempt = now.Add(w.backoff(item.Attempts))
+			if err := w.save(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		w.remove(item)
+		w.queue.Uploaded[item.Path] = &SyncEntry{
+			Size:       info.Size(),
+			ModTime:    info.ModTime(),
+			PhotoID:    photo.ID,
+			AlbumID:    photo.AlbumID,
+			UploadedAt: time.Now(),
+		}
+
+		// Once moved away the file can't be seen again, no need to remember it.
+		// A file that can't be moved stays recorded as uploaded, so it isn't uploaded again.
+		if w.opts.DoneDir != "" {
+			if err := w.moveToDone(item); err != nil {
+				log.Warn("Failed to move %s to the done directory, got error %s", item.Path, err.Error())
+			} else {
+				delete(w.queue.Uploaded, item.Path)
+			}
+		}
+
+		if err := w.save(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backoff returns the wait before the next attempt of a file which failed attempts times
+func (w *Watcher) backoff(attempts int) time.Duration {
+	d := w.opts.PollInterval
+	for i := 1; i < attempts && d < w.opts.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > w.opts.MaxBackoff {
+		return w.opts.MaxBackoff
+	}
+	return d
+}
+
+// remove takes the item out of the queue
+func (w *Watcher) remove(item *WatchItem) {
+	for i, pending := range w.queue.Pending {
+		if pending == item {
+			w.queue.Pending = append(w.queue.Pending[:i], w.queue.Pending[i+1:]...)
+			return
+		}
+	}
+}
+
+// moveToDone moves the uploaded file into the done directory
+func (w *Watcher) moveToDone(item *WatchItem) error {
+	rel, err := filepath.Rel(item.Dir, item.Path)
+	if err != nil {
+		return err
+	}
+
+	dst := filepath.Join(w.opts.DoneDir, rel)
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	// Never overwrite a file already in the done directory
+	ext := filepath.Ext(dst)
+	base := strings.TrimSuffix(dst, ext)
+	for i := 1; ; i++ {
+		if _, err := os.Stat(dst); os.IsNotExist(err) {
+			break
+		}
+		dst = fmt.Sprintf("%s_%d%s", base, i, ext)
+	}
+
+	log.Info("Move %s to %s", item.Path, dst)
+	return os.Rename(item.Path, dst)
+}
@@ -0,0 +1,243 @@
+package gphoto
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcher(t *testing.T) {
+	root, err := ioutil.TempDir("", "gphoto-watch")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	dir := filepath.Join(root, "inbox")
+	done := filepath.Join(root, "done")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "2021"), 0755))
+
+	var uploaded []string
+	attempts := 0
+	failing := true
+	upload := func(ctx context.Context, path string, photoID string) (*Photo, error) {
+		attempts++
+		if failing {
+			return nil, errors.New("boom")
+		}
+		uploaded = append(uploaded, path)
+		return &Photo{ID: "id-" + filepath.Base(path)}, nil
+	}
+
+	opts := WatchOptions{Dirs: []string{dir}, DoneDir: done, SettleTime: time.Minute, PollInterval: time.Minute, MaxBackoff: 3 * time.Minute}
+	w, err := newWatcher(opts, upload)
+	require.NoError(t, err)
+
+	file := filepath.Join(dir, "2021", "a.jpg")
+	require.NoError(t, ioutil.WriteFile(file, []byte("a"), 0644))
+	now := time.Now()
+
+	t.Run("WaitUntilTheFileStopsGrowing", func(t *testing.T) {
+		require.NoError(t, w.scan(now))
+		assert.Empty(t, w.Pending())
+
+		require.NoError(t, ioutil.WriteFile(file, []byte("ab"), 0644))
+		require.NoError(t, w.scan(now.Add(2*time.Minute)))
+		assert.Empty(t, w.Pending())
+
+		require.NoError(t, w.scan(now.Add(3*time.Minute)))
+		require.Len(t, w.Pending(), 1)
+		assert.Equal(t, file, w.Pending()[0].Path)
+	})
+
+	t.Run("KeepFailedFilesQueued", func(t *testing.T) {
+		require.NoError(t, w.process(context.Background(), now))
+		require.Len(t, w.Pending(), 1)
+		assert.Equal(t, 1, w.Pending()[0].Attempts)
+		assert.Equal(t, "boom", w.Pending()[0].LastError)
+		assert.Equal(t, now.Add(time.Minute), w.Pending()[0].NextAttempt)
+	})
+
+	t.Run("WaitBeforeTryingAgain", func(t *testing.T) {
+		require.NoError(t, w.process(context.Background(), now.Add(30*time.Second)))
+		assert.Equal(t, 1, attempts)
+
+		// The wait doubles after every failure, up to MaxBackoff
+		later := now.Add(time.Minute)
+		require.NoError(t, w.process(context.Background(), later))
+		assert.Equal(t, 2, attempts)
+		assert.Equal(t, later.Add(2*time.Minute), w.Pending()[0].NextAttempt)
+
+		later = later.Add(2 * time.Minute)
+		require.NoError(t, w.process(context.Background(), later))
+		assert.Equal(t, 3, attempts)
+		assert.Equal(t, later.Add(3*time.Minute), w.Pending()[0].NextAttempt)
+		now = later.Add(3 * time.Minute)
+	})
+
+	t.Run("RestoreTheQueueAfterARestart", func(t *testing.T) {
+		w, err = newWatcher(opts, upload)
+		require.NoError(t, err)
+		require.Len(t, w.Pending(), 1)
+		assert.Equal(t, file, w.Pending()[0].Path)
+	})
+
+	t.Run("UploadAndMoveToTheDoneFolder", func(t *testing.T) {
+		failing = false
+		require.NoError(t, w.process(context.Background(), now))
+		assert.Equal(t, []string{file}, uploaded)
+		assert.Empty(t, w.Pending())
+
+		_, err := os.Stat(file)
+		assert.True(t, os.IsNotExist(err))
+		_, err = os.Stat(filepath.Join(done, "2021", "a.jpg"))
+		assert.NoError(t, err)
+	})
+}
+
+func TestWatcherDoneDirFailure(t *testing.T) {
+	root, err := ioutil.TempDir("", "gphoto-watch")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	dir := filepath.Join(root, "inbox")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+
+	// A file where the done directory should be, so the uploaded files can't be moved
+	done := filepath.Join(root, "done")
+	require.NoError(t, ioutil.WriteFile(done, []byte("x"), 0644))
+
+	var uploaded []string
+	upload := func(ctx context.Context, path string, photoID string) (*Photo, error) {
+		uploaded = append(uploaded, path)
+		return &Photo{ID: "id-" + filepath.Base(path)}, nil
+	}
+
+	opts := WatchOptions{Dirs: []string{dir}, DoneDir: done, SettleTime: time.Minute}
+	w, err := newWatcher(opts, upload)
+	require.NoError(t, err)
+
+	a := filepath.Join(dir, "a.jpg")
+	b := filepath.Join(dir, "b.jpg")
+	require.NoError(t, ioutil.WriteFile(a, []byte("a"), 0644))
+	require.NoError(t, ioutil.WriteFile(b, []byte("b"), 0644))
+
+	now := time.Now()
+	require.NoError(t, w.scan(now))
+	require.NoError(t, w.scan(now.Add(time.Minute)))
+	require.Len(t, w.Pending(), 2)
+
+	// Every file is uploaded even though none can be moved
+	require.NoError(t, w.process(context.Background(), now))
+	assert.Equal(t, []string{a, b}, uploaded)
+	assert.Empty(t, w.Pending())
+
+	// The files left in place are remembered, they aren't uploaded again
+	require.NoError(t, w.scan(now.Add(2*time.Minute)))
+	require.NoError(t, w.scan(now.Add(3*time.Minute)))
+	assert.Empty(t, w.Pending())
+	assert.Equal(t, "id-a.jpg", w.queue.Uploaded[a].PhotoID)
+}
+
+func TestWatcherResume(t *testing.T) {
+	root, err := ioutil.TempDir("", "gphoto-watch")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	file := filepath.Join(root, "a.jpg")
+	require.NoError(t, ioutil.WriteFile(file, []byte("a"), 0644))
+
+	var resumed []string
+	failing := true
+	upload := func(ctx context.Context, path string, photoID string) (*Photo, error) {
+		resumed = append(resumed, photoID)
+		photo := &Photo{ID: "id-" + filepath.Base(path)}
+		if failing {
+			return photo, &UploadError{Stage: StageMoveToAlbum, Err: errors.New("boom"), Photo: photo}
+		}
+		photo.AlbumID = "album"
+		return photo, nil
+	}
+
+	opts := WatchOptions{Dirs: []string{root}, SettleTime: time.Minute, PollInterval: time.Minute}
+	w, err := newWatcher(opts, upload)
+	require.NoError(t, err)
+
+	now := time.Now()
+	require.NoError(t, w.scan(now))
+	require.NoError(t, w.scan(now.Add(time.Minute)))
+	require.Len(t, w.Pending(), 1)
+
+	require.NoError(t, w.process(context.Background(), now))
+	require.Len(t, w.Pending(), 1)
+	assert.Equal(t, "id-a.jpg", w.Pending()[0].PhotoID)
+
+	// The committed photo survives a restart, the next attempt only runs the stages left
+	w, err = newWatcher(opts, upload)
+	require.NoError(t, err)
+	failing = false
+	require.NoError(t, w.process(context.Background(), now.Add(time.Hour)))
+	assert.Equal(t, []string{"", "id-a.jpg"}, resumed)
+	assert.Empty(t, w.Pending())
+	assert.Equal(t, "album", w.queue.Uploaded[file].AlbumID)
+}
+
+func TestWatcherStop(t *testing.T) {
+	root, err := ioutil.TempDir("", "gphoto-watch")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	file := filepath.Join(root, "a.jpg")
+	require.NoError(t, ioutil.WriteFile(file, []byte("a"), 0644))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	upload := func(ctx context.Context, path string, photoID string) (*Photo, error) {
+		// The watcher is stopped while the file is uploaded
+		cancel()
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	w, err := newWatcher(WatchOptions{Dirs: []string{root}, SettleTime: time.Minute}, upload)
+	require.NoError(t, err)
+
+	now := time.Now()
+	require.NoError(t, w.scan(now))
+	require.NoError(t, w.scan(now.Add(time.Minute)))
+	require.Len(t, w.Pending(), 1)
+
+	require.NoError(t, w.process(ctx, now))
+	require.Len(t, w.Pending(), 1)
+	assert.Equal(t, 0, w.Pending()[0].Attempts)
+	assert.True(t, w.Pending()[0].NextAttempt.IsZero())
+}
+
+func TestWatcherSkipQueueFile(t *testing.T) {
+	root, err := ioutil.TempDir("", "gphoto-watch")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	upload := func(ctx context.Context, path string, photoID string) (*Photo, error) {
+		return &Photo{ID: "id-" + filepath.Base(path)}, nil
+	}
+
+	queueFile := filepath.Join(root, "queue.json")
+	w, err := newWatcher(WatchOptions{Dirs: []string{root}, QueueFile: queueFile, SettleTime: time.Minute}, upload)
+	require.NoError(t, err)
+
+	file := filepath.Join(root, "a.jpg")
+	require.NoError(t, ioutil.WriteFile(file, []byte("a"), 0644))
+	require.NoError(t, ioutil.WriteFile(queueFile, []byte("{}"), 0644))
+	require.NoError(t, ioutil.WriteFile(queueFile+".tmp", []byte("{}"), 0644))
+
+	now := time.Now()
+	require.NoError(t, w.scan(now))
+	require.NoError(t, w.scan(now.Add(time.Minute)))
+	require.Len(t, w.Pending(), 1)
+	assert.Equal(t, file, w.Pending()[0].Path)
+}